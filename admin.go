@@ -0,0 +1,655 @@
+package gochan
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ilyakaznacheev/gochan/config"
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// AdminBanRepr is a context for admin_ban.html template
+type AdminBanRepr struct {
+	Bans []BanRepr
+}
+
+// AdminBoardRepr is a part of admin_board.html template context
+type AdminBoardRepr struct {
+	Key     string
+	Name    string
+	Captcha bool
+}
+
+// AdminThreadRepr is a part of admin_thread.html template context
+type AdminThreadRepr struct {
+	Key    string
+	Title  string
+	Time   string
+	Sticky bool
+	Locked bool
+}
+
+// FilterRepr is a part of admin_filter.html template context
+type FilterRepr struct {
+	Key         string
+	Board       string
+	Pattern     string
+	IsRegex     bool
+	Action      string
+	Replacement string
+}
+
+// FilterMatchRepr is a part of admin_filter.html template context
+type FilterMatchRepr struct {
+	Filter string
+	Action string
+	Board  string
+	Author string
+	Thread string
+	Post   string
+	Text   string
+	Time   string
+}
+
+// AdminFilterRepr is a context for admin_filter.html template
+type AdminFilterRepr struct {
+	Filters []FilterRepr
+	Matches []FilterMatchRepr
+}
+
+const filterMatchLimit = 100
+
+// ReportRepr is a part of admin_report.html template context
+type ReportRepr struct {
+	Key    string
+	Reason string
+	Count  int
+	Time   string
+	Thread string
+	Post   PostRepr
+}
+
+// ModActionRepr is a part of admin_log.html template context
+type ModActionRepr struct {
+	Key       string
+	Moderator string
+	Action    string
+	Board     string
+	Thread    string
+	Post      string
+	Image     string
+	Ban       string
+	Reason    string
+	Time      string
+}
+
+// AdminModLogRepr is a context for admin_log.html template
+type AdminModLogRepr struct {
+	Filter  url.Values
+	Actions []ModActionRepr
+}
+
+const (
+	modActionLimit  = 200
+	modLogDateInput = "2006-01-02"
+)
+
+// adminAuth protects admin handlers with HTTP basic auth
+func adminAuth(conf config.ConfigAdmin, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		// empty password would let anybody in
+		if !ok || conf.Password == "" ||
+			subtle.ConstantTimeCompare([]byte(user), []byte(conf.User)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(pass), []byte(conf.Password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="gochan admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminSameOrigin rejects state-changing admin requests sent from other sites,
+// browser passes admin credentials with cross-site form posts as well
+func adminSameOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		source := r.Header.Get("Origin")
+		if source == "" {
+			source = r.Header.Get("Referer")
+		}
+		sourceURL, err := url.Parse(source)
+		if source == "" || err != nil || !strings.EqualFold(sourceURL.Host, r.Host) {
+			http.Error(w, "cross-origin request", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// modInfo returns moderator name and reason of admin request
+func modInfo(r *http.Request, reason string) model.ModInfo {
+	user, _, _ := r.BasicAuth()
+	return model.ModInfo{
+		Moderator: user,
+		Reason:    reason,
+	}
+}
+
+// AdminPage loads admin cockpit
+func (rh *ChanRequestHandler) AdminPage(w http.ResponseWriter, r *http.Request) {
+	rh.renderPage(w, r, http.StatusOK, "admin.html", nil)
+}
+
+// AdminBanPage returns ban list with appeals
+func (rh *ChanRequestHandler) AdminBanPage(w http.ResponseWriter, r *http.Request) {
+	banData, err := rh.model.banModel.GetList(r.Context())
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	var ctxAdmin AdminBanRepr
+	ctxAdmin.Bans = make([]BanRepr, 0, len(banData))
+
+	for _, banItem := range banData {
+		appealData, err := rh.model.banModel.GetAppeals(r.Context(), banItem.Key)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "can't get ban appeals", "ban", banItem.Key, "err", err)
+		}
+		ctxAdmin.Bans = append(ctxAdmin.Bans, newBanRepr(banItem, appealData))
+	}
+
+	rh.renderPage(w, r, http.StatusOK, "admin_ban.html", ctxAdmin)
+}
+
+// AdminAddBan creates new ban
+func (rh *ChanRequestHandler) AdminAddBan(w http.ResponseWriter, r *http.Request) {
+	newBan := model.Ban{
+		Reason:           r.FormValue("reason"),
+		CreationDateTime: time.Now(),
+	}
+
+	if inputIP := strings.TrimSpace(r.FormValue("ip")); inputIP != "" {
+		newBan.IP = &inputIP
+	}
+	if inputAuthor := strings.TrimSpace(r.FormValue("author")); inputAuthor != "" {
+		authorID := model.AuthorKey(inputAuthor)
+		newBan.Author = &authorID
+	}
+	if inputBoard := strings.TrimSpace(r.FormValue("board")); inputBoard != "" {
+		boardName := model.BoardKey(inputBoard)
+		newBan.Board = &boardName
+	}
+
+	// empty or zero duration means permanent ban
+	if inputHours, _ := strconv.Atoi(r.FormValue("hours")); inputHours > 0 {
+		expiration := newBan.CreationDateTime.Add(time.Duration(inputHours) * time.Hour)
+		newBan.ExpirationDateTime = &expiration
+	}
+
+	banID, err := rh.model.banModel.PutBan(r.Context(), newBan, modInfo(r, newBan.Reason))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "new ban", "ban", banID)
+
+	http.Redirect(w, r, "/admin/ban", http.StatusFound)
+}
+
+// AdminLiftBan lifts certain ban
+func (rh *ChanRequestHandler) AdminLiftBan(w http.ResponseWriter, r *http.Request) {
+	requestParams := mux.Vars(r)
+	banID, _ := strconv.Atoi(requestParams["id"])
+
+	err := rh.model.banModel.LiftBan(r.Context(), model.BanKey(banID), modInfo(r, r.FormValue("reason")))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "ban lifted", "ban", banID)
+
+	http.Redirect(w, r, "/admin/ban", http.StatusFound)
+}
+
+// AdminBoardPage returns board list with settings
+func (rh *ChanRequestHandler) AdminBoardPage(w http.ResponseWriter, r *http.Request) {
+	modelData := rh.model.boardModel.GetList(r.Context())
+
+	ctxBoards := make([]AdminBoardRepr, 0, len(modelData))
+
+	for _, board := range modelData {
+		ctxBoards = append(ctxBoards, AdminBoardRepr{
+			Key:     string(board.Key),
+			Name:    board.Name,
+			Captcha: board.Captcha,
+		})
+	}
+
+	rh.renderPage(w, r, http.StatusOK, "admin_board.html", struct{ Boards []AdminBoardRepr }{ctxBoards})
+}
+
+// AdminToggleCaptcha switches captcha requirement on the board
+func (rh *ChanRequestHandler) AdminToggleCaptcha(w http.ResponseWriter, r *http.Request) {
+	requestParams := mux.Vars(r)
+
+	boardData, err := rh.model.boardModel.GetItem(r.Context(), model.BoardKey(requestParams["board"]))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	boardData.Captcha = !boardData.Captcha
+	err = rh.model.boardModel.UpdateBoard(r.Context(), *boardData, modInfo(r, fmt.Sprintf("captcha set to %t", boardData.Captcha)))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "board captcha changed", "board", boardData.Key, "captcha", boardData.Captcha)
+
+	http.Redirect(w, r, "/admin/board", http.StatusFound)
+}
+
+// AdminThreadPage returns board thread list with moderation flags
+func (rh *ChanRequestHandler) AdminThreadPage(w http.ResponseWriter, r *http.Request) {
+	requestParams := mux.Vars(r)
+
+	boardData, err := rh.model.boardModel.GetItem(r.Context(), model.BoardKey(requestParams["board"]))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	modelData, err := rh.model.threadModel.GetTheadsByBoard(r.Context(), boardData.Key)
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	ctxThreads := make([]AdminThreadRepr, 0, len(modelData))
+
+	for _, threadItem := range modelData {
+		ctxThreads = append(ctxThreads, AdminThreadRepr{
+			Key:    strconv.Itoa(int(threadItem.Key)),
+			Title:  threadItem.Title,
+			Time:   threadItem.CreationDateTime.Format(timeFormat),
+			Sticky: threadItem.Sticky,
+			Locked: threadItem.Locked,
+		})
+	}
+
+	rh.renderPage(w, r, http.StatusOK, "admin_thread.html", struct {
+		Board   AdminBoardRepr
+		Threads []AdminThreadRepr
+	}{AdminBoardRepr{
+		Key:     string(boardData.Key),
+		Name:    boardData.Name,
+		Captcha: boardData.Captcha,
+	}, ctxThreads})
+}
+
+// AdminToggleSticky pins or unpins the thread
+func (rh *ChanRequestHandler) AdminToggleSticky(w http.ResponseWriter, r *http.Request) {
+	rh.toggleThreadFlag(w, r, func(threadData *model.Thread, mod model.ModInfo) error {
+		return rh.model.threadModel.SetSticky(r.Context(), threadData.Key, !threadData.Sticky, mod)
+	})
+}
+
+// AdminToggleLock locks or unlocks the thread
+func (rh *ChanRequestHandler) AdminToggleLock(w http.ResponseWriter, r *http.Request) {
+	rh.toggleThreadFlag(w, r, func(threadData *model.Thread, mod model.ModInfo) error {
+		return rh.model.threadModel.SetLocked(r.Context(), threadData.Key, !threadData.Locked, mod)
+	})
+}
+
+func (rh *ChanRequestHandler) toggleThreadFlag(w http.ResponseWriter, r *http.Request,
+	toggle func(*model.Thread, model.ModInfo) error) {
+	requestParams := mux.Vars(r)
+	threadID, _ := strconv.Atoi(requestParams["id"])
+
+	threadData, err := rh.model.threadModel.GetThread(r.Context(), model.ThreadKey(threadID))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	err = toggle(threadData, modInfo(r, r.FormValue("reason")))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/board/"+string(threadData.BoardName), http.StatusFound)
+}
+
+// AdminFilterPage returns filter list and filter match log
+func (rh *ChanRequestHandler) AdminFilterPage(w http.ResponseWriter, r *http.Request) {
+	filterData, err := rh.model.filterModel.GetList(r.Context())
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	matchData, err := rh.model.filterModel.GetMatchList(r.Context(), filterMatchLimit)
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	var ctxAdmin AdminFilterRepr
+	ctxAdmin.Filters = make([]FilterRepr, 0, len(filterData))
+
+	for _, filterItem := range filterData {
+		ctxAdmin.Filters = append(ctxAdmin.Filters, FilterRepr{
+			Key:         strconv.Itoa(int(filterItem.Key)),
+			Board:       filterItem.GetBoard(),
+			Pattern:     filterItem.Pattern,
+			IsRegex:     filterItem.IsRegex,
+			Action:      string(filterItem.Action),
+			Replacement: filterItem.Replacement,
+		})
+	}
+
+	ctxAdmin.Matches = make([]FilterMatchRepr, 0, len(matchData))
+
+	for _, matchItem := range matchData {
+		ctxMatch := FilterMatchRepr{
+			Filter: strconv.Itoa(int(matchItem.Filter)),
+			Action: string(matchItem.Action),
+			Board:  string(matchItem.Board),
+			Author: string(matchItem.Author),
+			Text:   matchItem.Text,
+			Time:   matchItem.CreationDateTime.Format(timeFormat),
+		}
+		if matchItem.Thread != nil {
+			ctxMatch.Thread = matchItem.Thread.String()
+		}
+		if matchItem.Post != nil {
+			ctxMatch.Post = matchItem.Post.String()
+		}
+		ctxAdmin.Matches = append(ctxAdmin.Matches, ctxMatch)
+	}
+
+	rh.renderPage(w, r, http.StatusOK, "admin_filter.html", ctxAdmin)
+}
+
+// AdminAddFilter creates new word filter
+func (rh *ChanRequestHandler) AdminAddFilter(w http.ResponseWriter, r *http.Request) {
+	newFilter := model.Filter{
+		Pattern:     r.FormValue("pattern"),
+		IsRegex:     r.FormValue("regex") != "",
+		Action:      model.FilterAction(r.FormValue("action")),
+		Replacement: r.FormValue("replacement"),
+	}
+	if inputBoard := strings.TrimSpace(r.FormValue("board")); inputBoard != "" {
+		boardName := model.BoardKey(inputBoard)
+		newFilter.Board = &boardName
+	}
+
+	filterID, err := rh.model.filterModel.PutFilter(r.Context(), newFilter, modInfo(r, r.FormValue("reason")))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "new filter", "filter", filterID)
+
+	http.Redirect(w, r, "/admin/filter", http.StatusFound)
+}
+
+// AdminDeleteFilter removes certain word filter
+func (rh *ChanRequestHandler) AdminDeleteFilter(w http.ResponseWriter, r *http.Request) {
+	requestParams := mux.Vars(r)
+	filterID, _ := strconv.Atoi(requestParams["id"])
+
+	err := rh.model.filterModel.DeleteFilter(r.Context(), model.FilterKey(filterID), modInfo(r, r.FormValue("reason")))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "filter deleted", "filter", filterID)
+
+	http.Redirect(w, r, "/admin/filter", http.StatusFound)
+}
+
+// AdminReportPage returns moderation queue
+func (rh *ChanRequestHandler) AdminReportPage(w http.ResponseWriter, r *http.Request) {
+	reportData, err := rh.model.reportModel.GetQueue(r.Context())
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	ctxReports := make([]ReportRepr, 0, len(reportData))
+
+	for _, reportItem := range reportData {
+		postItem, err := rh.model.postModel.GetPost(r.Context(), reportItem.Post)
+		if err != nil {
+			slog.WarnContext(r.Context(), "can't get reported post", "post", reportItem.Post, "err", err)
+			continue
+		}
+
+		ctxReports = append(ctxReports, ReportRepr{
+			Key:    strconv.Itoa(int(reportItem.Key)),
+			Reason: reportItem.Reason,
+			Count:  reportItem.Count,
+			Time:   reportItem.UpdateDateTime.Format(timeFormat),
+			Thread: postItem.Thread.String(),
+			Post: PostRepr{
+				Key:       strconv.Itoa(int(postItem.Key)),
+				Author:    string(postItem.Author),
+				Time:      postItem.CreationDateTime.Format(timeFormat),
+				Text:      postItem.Text,
+				ImagePath: postItem.GetImagePath(),
+				HasImage:  postItem.ImagePath != nil,
+			},
+		})
+	}
+
+	rh.renderPage(w, r, http.StatusOK, "admin_report.html", struct{ Reports []ReportRepr }{ctxReports})
+}
+
+// AdminDismissReport removes report from the queue without action
+func (rh *ChanRequestHandler) AdminDismissReport(w http.ResponseWriter, r *http.Request) {
+	requestParams := mux.Vars(r)
+	reportID, _ := strconv.Atoi(requestParams["id"])
+
+	err := rh.model.reportModel.Dismiss(r.Context(), model.ReportKey(reportID), modInfo(r, r.FormValue("reason")))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "report dismissed", "report", reportID)
+
+	http.Redirect(w, r, "/admin/report", http.StatusFound)
+}
+
+// AdminDeleteReported deletes reported post
+func (rh *ChanRequestHandler) AdminDeleteReported(w http.ResponseWriter, r *http.Request) {
+	requestParams := mux.Vars(r)
+	reportID, _ := strconv.Atoi(requestParams["id"])
+
+	reportData, err := rh.model.reportModel.GetReport(r.Context(), model.ReportKey(reportID))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	mod := modInfo(r, r.FormValue("reason"))
+
+	// report is resolved first, because it is deleted together with the post
+	err = rh.model.reportModel.Resolve(r.Context(), reportData.Key, mod)
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	err = rh.model.postModel.DeletePost(r.Context(), reportData.Post, mod)
+	if err != nil {
+		// return the report to the queue, since the post is still there
+		if reopenErr := rh.model.reportModel.Reopen(r.Context(), reportData.Key, mod); reopenErr != nil {
+			slog.ErrorContext(r.Context(), "can't reopen report", "report", reportData.Key, "err", reopenErr)
+		}
+		rh.renderError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "reported post deleted", "post", reportData.Post)
+
+	http.Redirect(w, r, "/admin/report", http.StatusFound)
+}
+
+// AdminBanReported bans author of reported post
+func (rh *ChanRequestHandler) AdminBanReported(w http.ResponseWriter, r *http.Request) {
+	requestParams := mux.Vars(r)
+	reportID, _ := strconv.Atoi(requestParams["id"])
+
+	reportData, err := rh.model.reportModel.GetReport(r.Context(), model.ReportKey(reportID))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	postData, err := rh.model.postModel.GetPost(r.Context(), reportData.Post)
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	newBan := model.Ban{
+		Author:           &postData.Author,
+		Reason:           r.FormValue("reason"),
+		CreationDateTime: time.Now(),
+	}
+	if inputHours, _ := strconv.Atoi(r.FormValue("hours")); inputHours > 0 {
+		expiration := newBan.CreationDateTime.Add(time.Duration(inputHours) * time.Hour)
+		newBan.ExpirationDateTime = &expiration
+	}
+
+	mod := modInfo(r, newBan.Reason)
+
+	banID, err := rh.model.banModel.PutBan(r.Context(), newBan, mod)
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	err = rh.model.reportModel.Resolve(r.Context(), reportData.Key, mod)
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "new ban", "ban", banID, "post", reportData.Post)
+
+	http.Redirect(w, r, "/admin/report", http.StatusFound)
+}
+
+// parseModActionFilter reads moderation log search criteria from query string
+func parseModActionFilter(r *http.Request) model.ModActionFilter {
+	query := r.URL.Query()
+	filter := model.ModActionFilter{
+		Moderator: query.Get("moderator"),
+		Action:    model.ModActionType(query.Get("action")),
+		Board:     model.BoardKey(query.Get("board")),
+	}
+	if since, err := time.Parse(modLogDateInput, query.Get("since")); err == nil {
+		filter.Since = &since
+	}
+	if until, err := time.Parse(modLogDateInput, query.Get("until")); err == nil {
+		// include the whole day
+		until = until.AddDate(0, 0, 1)
+		filter.Until = &until
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil {
+		filter.Limit = limit
+	}
+	return filter
+}
+
+// AdminModLogPage returns moderation log
+func (rh *ChanRequestHandler) AdminModLogPage(w http.ResponseWriter, r *http.Request) {
+	filter := parseModActionFilter(r)
+	if filter.Limit <= 0 {
+		filter.Limit = modActionLimit
+	}
+
+	actionData, err := rh.model.modActionModel.GetList(r.Context(), filter)
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	ctxAdmin := AdminModLogRepr{
+		Filter:  r.URL.Query(),
+		Actions: make([]ModActionRepr, 0, len(actionData)),
+	}
+
+	for _, actionItem := range actionData {
+		ctxAction := ModActionRepr{
+			Key:       strconv.Itoa(int(actionItem.Key)),
+			Moderator: actionItem.Moderator,
+			Action:    string(actionItem.Action),
+			Reason:    actionItem.Reason,
+			Time:      actionItem.CreationDateTime.Format(timeFormat),
+		}
+		if actionItem.Board != nil {
+			ctxAction.Board = string(*actionItem.Board)
+		}
+		if actionItem.Thread != nil {
+			ctxAction.Thread = actionItem.Thread.String()
+		}
+		if actionItem.Post != nil {
+			ctxAction.Post = actionItem.Post.String()
+		}
+		if actionItem.Image != nil {
+			ctxAction.Image = actionItem.Image.String()
+		}
+		if actionItem.Ban != nil {
+			ctxAction.Ban = strconv.Itoa(int(*actionItem.Ban))
+		}
+		ctxAdmin.Actions = append(ctxAdmin.Actions, ctxAction)
+	}
+
+	rh.renderPage(w, r, http.StatusOK, "admin_log.html", ctxAdmin)
+}
+
+// AdminModLogExport returns moderation log as JSON lines
+func (rh *ChanRequestHandler) AdminModLogExport(w http.ResponseWriter, r *http.Request) {
+	actionData, err := rh.model.modActionModel.GetList(r.Context(), parseModActionFilter(r))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="mod_action.jsonl"`)
+
+	encoder := json.NewEncoder(w)
+	for _, actionItem := range actionData {
+		if err := encoder.Encode(actionItem); err != nil {
+			slog.WarnContext(r.Context(), "can't write moderation log", "err", err)
+			return
+		}
+	}
+}
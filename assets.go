@@ -0,0 +1,79 @@
+package gochan
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+const (
+	staticDir   = "static"
+	templateDir = "static/template"
+	schemaDir   = "schema"
+)
+
+// embeddedAssets contains templates, static files and GraphQL schema,
+// so the server doesn't depend on the working directory
+//
+//go:embed static schema/*.graphql
+var embeddedAssets embed.FS
+
+// getAssets returns embedded assets, overridden by files of the directory if it is set.
+// The directory mirrors embedded layout, e.g. static/template/board.html
+func getAssets(overrideDir string) fs.FS {
+	if overrideDir == "" {
+		return embeddedAssets
+	}
+	return &overlayFS{
+		upper: os.DirFS(overrideDir),
+		lower: embeddedAssets,
+	}
+}
+
+// overlayFS looks up files in the upper file system first and falls back to the lower one.
+// Directory listings contain files of both
+type overlayFS struct {
+	upper fs.FS
+	lower fs.FS
+}
+
+// Open opens the file of the upper file system, or the lower one if there is no such file.
+// Directories are always opened from the lower one, use ReadDir to list both
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	if info, err := fs.Stat(o.upper, name); err == nil && !info.IsDir() {
+		return o.upper.Open(name)
+	}
+	file, err := o.lower.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.upper.Open(name)
+	}
+	return file, err
+}
+
+// ReadDir returns merged directory listing, upper entries replace lower ones with the same name
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	upperList, upperErr := fs.ReadDir(o.upper, name)
+	lowerList, lowerErr := fs.ReadDir(o.lower, name)
+	if upperErr != nil && lowerErr != nil {
+		return nil, lowerErr
+	}
+
+	entryMap := make(map[string]fs.DirEntry, len(upperList)+len(lowerList))
+	for _, entry := range lowerList {
+		entryMap[entry.Name()] = entry
+	}
+	for _, entry := range upperList {
+		entryMap[entry.Name()] = entry
+	}
+
+	entryList := make([]fs.DirEntry, 0, len(entryMap))
+	for _, entry := range entryMap {
+		entryList = append(entryList, entry)
+	}
+	sort.Slice(entryList, func(i, j int) bool {
+		return entryList[i].Name() < entryList[j].Name()
+	})
+	return entryList, nil
+}
@@ -0,0 +1,141 @@
+// Package captcha generates distorted digit images without any external service
+package captcha
+
+import (
+	"crypto/rand"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	mrand "math/rand"
+)
+
+const (
+	// Width is a captcha image width
+	Width = 200
+	// Height is a captcha image height
+	Height = 70
+
+	glyphWidth  = 5
+	glyphHeight = 7
+	dotSize     = 5
+)
+
+// glyphs is a 5x7 bitmap font for digits
+var glyphs = map[rune][glyphHeight]string{
+	'0': {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2': {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3': {"#####", "...#.", "..#..", "...#.", "....#", "#...#", ".###."},
+	'4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6': {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9': {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+}
+
+// RandomDigits returns cryptographically random digit string of given length
+func RandomDigits(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		for i := range b {
+			b[i] = byte(mrand.Intn(256))
+		}
+	}
+	for i := range b {
+		b[i] = '0' + b[i]%10
+	}
+	return string(b)
+}
+
+// Render draws distorted captcha image for given digits
+func Render(text string) image.Image {
+	src := image.NewGray(image.Rect(0, 0, Width, Height))
+	fill(src, color.Gray{Y: 255})
+
+	step := Width / (len(text) + 1)
+	for i, char := range text {
+		glyph, ok := glyphs[char]
+		if !ok {
+			continue
+		}
+		x0 := step/2 + i*step + mrand.Intn(step/4+1)
+		y0 := (Height-glyphHeight*dotSize)/2 + mrand.Intn(11) - 5
+		drawGlyph(src, glyph, x0, y0)
+	}
+
+	dst := image.NewGray(src.Bounds())
+	fill(dst, color.Gray{Y: 255})
+
+	// wave distortion
+	ampX, ampY := 3+mrand.Float64()*3, 3+mrand.Float64()*3
+	periodX, periodY := 8+mrand.Float64()*6, 20+mrand.Float64()*10
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			sx := x + int(ampX*math.Sin(float64(y)/periodX))
+			sy := y + int(ampY*math.Sin(float64(x)/periodY))
+			if sx >= 0 && sx < Width && sy >= 0 && sy < Height {
+				dst.SetGray(x, y, src.GrayAt(sx, sy))
+			}
+		}
+	}
+
+	// noise lines
+	for i := 0; i < 4; i++ {
+		drawLine(dst,
+			mrand.Intn(Width), mrand.Intn(Height),
+			mrand.Intn(Width), mrand.Intn(Height),
+			color.Gray{Y: uint8(mrand.Intn(128))},
+		)
+	}
+
+	// noise dots
+	for i := 0; i < Width*Height/20; i++ {
+		dst.SetGray(mrand.Intn(Width), mrand.Intn(Height), color.Gray{Y: uint8(mrand.Intn(256))})
+	}
+
+	return dst
+}
+
+// WritePNG renders captcha image for given digits as PNG
+func WritePNG(w io.Writer, text string) error {
+	return png.Encode(w, Render(text))
+}
+
+func fill(img *image.Gray, c color.Gray) {
+	for i := range img.Pix {
+		img.Pix[i] = c.Y
+	}
+}
+
+func drawGlyph(img *image.Gray, glyph [glyphHeight]string, x0, y0 int) {
+	shade := color.Gray{Y: uint8(mrand.Intn(80))}
+	for gy, line := range glyph {
+		for gx, dot := range line {
+			if dot != '#' {
+				continue
+			}
+			for dy := 0; dy < dotSize; dy++ {
+				for dx := 0; dx < dotSize; dx++ {
+					img.SetGray(x0+gx*dotSize+dx, y0+gy*dotSize+dy, shade)
+				}
+			}
+		}
+	}
+}
+
+func drawLine(img *image.Gray, x0, y0, x1, y1 int, c color.Gray) {
+	dx, dy := math.Abs(float64(x1-x0)), math.Abs(float64(y1-y0))
+	steps := int(math.Max(dx, dy))
+	if steps == 0 {
+		return
+	}
+	for i := 0; i <= steps; i++ {
+		x := x0 + (x1-x0)*i/steps
+		y := y0 + (y1-y0)*i/steps
+		img.SetGray(x, y, c)
+		img.SetGray(x, y+1, c)
+	}
+}
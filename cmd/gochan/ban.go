@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+const timeFormat = "2006-01-02 15:04"
+
+// banCommand handles "ban add|list"
+func banCommand(a *app, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	banModel := model.NewBanModel(a.repo, a.storage.Ban, a.modLog)
+	ctx := context.Background()
+
+	flagSet := flag.NewFlagSet("ban "+args[0], flag.ContinueOnError)
+	switch args[0] {
+	case "add":
+		ip := flagSet.String("ip", "", "banned IP or CIDR")
+		author := flagSet.String("author", "", "banned author ID")
+		board := flagSet.String("board", "", "board key, empty for all boards")
+		reason := flagSet.String("reason", "", "ban reason shown to the poster")
+		duration := flagSet.Duration("duration", 0, "ban duration like 24h, zero for permanent ban")
+		if err := flagSet.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		newBan := model.Ban{
+			Reason:           *reason,
+			CreationDateTime: now,
+		}
+		if *ip != "" {
+			newBan.IP = ip
+		}
+		if *author != "" {
+			authorKey := model.AuthorKey(*author)
+			newBan.Author = &authorKey
+		}
+		if *board != "" {
+			boardKey := model.BoardKey(*board)
+			newBan.Board = &boardKey
+		}
+		if *duration > 0 {
+			expires := now.Add(*duration)
+			newBan.ExpirationDateTime = &expires
+		}
+
+		banID, err := banModel.PutBan(ctx, newBan, modInfo(*reason))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("ban #%d added\n", banID)
+
+	case "list":
+		all := flagSet.Bool("all", false, "show expired bans too")
+		if err := flagSet.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		banList, err := banModel.GetList(ctx)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tIP\tAUTHOR\tBOARD\tCREATED\tEXPIRES\tREASON")
+		for _, banItem := range banList {
+			if !*all && !banItem.IsActive(now) {
+				continue
+			}
+			ip, author, board, expires := "-", "-", "all", "never"
+			if banItem.IP != nil {
+				ip = *banItem.IP
+			}
+			if banItem.Author != nil {
+				author = string(*banItem.Author)
+			}
+			if banItem.Board != nil {
+				board = string(*banItem.Board)
+			}
+			if banItem.ExpirationDateTime != nil {
+				expires = banItem.ExpirationDateTime.Format(timeFormat)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				banItem.Key, ip, author, board,
+				banItem.CreationDateTime.Format(timeFormat), expires, banItem.Reason,
+			)
+		}
+		return w.Flush()
+
+	default:
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// boardCommand handles "board create|list|delete"
+func boardCommand(a *app, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	boardModel := model.NewBoardModel(a.repo, a.storage.Board, a.modLog)
+	ctx := context.Background()
+
+	flagSet := flag.NewFlagSet("board "+args[0], flag.ContinueOnError)
+	switch args[0] {
+	case "create":
+		key := flagSet.String("key", "", "board key used in URL, e.g. b")
+		name := flagSet.String("name", "", "board name")
+		captcha := flagSet.Bool("captcha", false, "require captcha to post")
+		reason := flagSet.String("reason", "", "moderation log reason")
+		if err := flagSet.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		err := boardModel.CreateBoard(ctx, model.Board{
+			Key:     model.BoardKey(*key),
+			Name:    *name,
+			Captcha: *captcha,
+		}, modInfo(*reason))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("board /%s/ created\n", *key)
+
+	case "list":
+		if err := flagSet.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		boardList, err := a.storage.Board.GetBoardList(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tNAME\tCAPTCHA")
+		for _, boardItem := range boardList {
+			fmt.Fprintf(w, "%s\t%s\t%t\n", boardItem.Key, boardItem.Name, boardItem.Captcha)
+		}
+		return w.Flush()
+
+	case "delete":
+		key := flagSet.String("key", "", "board key")
+		reason := flagSet.String("reason", "", "moderation log reason")
+		if err := flagSet.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		err := boardModel.DeleteBoard(ctx, model.BoardKey(*key), modInfo(*reason))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("board /%s/ deleted with all threads and posts\n", *key)
+
+	default:
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// cacheCommand handles "cache flush"
+func cacheCommand(a *app, args []string) error {
+	if len(args) != 1 || args[0] != "flush" {
+		return errUsage
+	}
+
+	deleted, err := a.repo.FlushCache(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d cache keys deleted\n", deleted)
+	return nil
+}
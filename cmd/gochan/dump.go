@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// dump is a JSON snapshot of board content.
+// Keys of threads and posts are informational, import assigns new ones
+type dump struct {
+	Images []*dumpImage `json:"images"`
+	Boards []*dumpBoard `json:"boards"`
+}
+
+type dumpImage struct {
+	Key      string `json:"key"`
+	FilePath string `json:"filepath"`
+}
+
+type dumpBoard struct {
+	Key     string        `json:"key"`
+	Name    string        `json:"name"`
+	Captcha bool          `json:"captcha"`
+	Threads []*dumpThread `json:"threads"`
+}
+
+type dumpThread struct {
+	Key     int         `json:"key"`
+	Title   string      `json:"title"`
+	Author  string      `json:"author"`
+	Created time.Time   `json:"created"`
+	Image   string      `json:"image,omitempty"`
+	Sticky  bool        `json:"sticky"`
+	Locked  bool        `json:"locked"`
+	Posts   []*dumpPost `json:"posts"` // the first post is OP
+}
+
+type dumpPost struct {
+	Key     int       `json:"key"`
+	Author  string    `json:"author"`
+	Created time.Time `json:"created"`
+	Text    string    `json:"text"`
+	Image   string    `json:"image,omitempty"`
+}
+
+// imageKeys maps image file path to image key
+func imageKeys(imageList []*dumpImage) map[string]string {
+	keyMap := make(map[string]string, len(imageList))
+	for _, imageItem := range imageList {
+		keyMap[imageItem.FilePath] = imageItem.Key
+	}
+	return keyMap
+}
+
+// exportCommand writes all boards, threads, posts and images as JSON
+func exportCommand(a *app, args []string) error {
+	flagSet := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := flagSet.String("o", "", "output file, stdout if empty")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	boardDAC := a.storage.Board
+	threadDAC := a.storage.Thread
+	postDAC := a.storage.Post
+	imageDAC := a.storage.Image
+
+	var data dump
+
+	imageList, err := imageDAC.GetImageList(ctx)
+	if err != nil {
+		return err
+	}
+	for _, imageItem := range imageList {
+		data.Images = append(data.Images, &dumpImage{
+			Key:      uuid.UUID(imageItem.Key).String(),
+			FilePath: imageItem.FilePath,
+		})
+	}
+
+	boardList, err := boardDAC.GetBoardList(ctx)
+	if err != nil {
+		return err
+	}
+	for _, boardItem := range boardList {
+		dumpBoardItem := &dumpBoard{
+			Key:     string(boardItem.Key),
+			Name:    boardItem.Name,
+			Captcha: boardItem.Captcha,
+		}
+
+		threadList, err := threadDAC.GetTheadsByBoard(ctx, boardItem.Key)
+		if err != nil {
+			return err
+		}
+		sort.Slice(threadList, func(i, j int) bool {
+			return threadList[i].Key < threadList[j].Key
+		})
+		for _, threadItem := range threadList {
+			dumpThreadItem := &dumpThread{
+				Key:     int(threadItem.Key),
+				Title:   threadItem.Title,
+				Author:  string(threadItem.AuthorID),
+				Created: threadItem.CreationDateTime,
+				Image:   threadItem.GetImagePath(),
+				Sticky:  threadItem.Sticky,
+				Locked:  threadItem.Locked,
+			}
+
+			postList, err := postDAC.GetPostsByThread(ctx, threadItem.Key)
+			if err != nil {
+				return err
+			}
+			sort.Slice(postList, func(i, j int) bool {
+				return postList[i].Key < postList[j].Key
+			})
+			for _, postItem := range postList {
+				dumpThreadItem.Posts = append(dumpThreadItem.Posts, &dumpPost{
+					Key:     int(postItem.Key),
+					Author:  string(postItem.Author),
+					Created: postItem.CreationDateTime,
+					Text:    postItem.Text,
+					Image:   postItem.GetImagePath(),
+				})
+			}
+			dumpBoardItem.Threads = append(dumpBoardItem.Threads, dumpThreadItem)
+		}
+		data.Boards = append(data.Boards, dumpBoardItem)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&data)
+}
+
+// importCommand loads JSON made by export. Boards must not exist yet,
+// threads and posts get new keys
+func importCommand(a *app, args []string) error {
+	flagSet := flag.NewFlagSet("import", flag.ContinueOnError)
+	input := flagSet.String("i", "", "input file, stdin if empty")
+	if err := flagSet.Parse(args); err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "" {
+		file, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var data dump
+	err := json.NewDecoder(r).Decode(&data)
+	if err != nil {
+		return fmt.Errorf("wrong dump: %v", err)
+	}
+
+	ctx := context.Background()
+	mod := modInfo("import")
+	boardModel := model.NewBoardModel(a.repo, a.storage.Board, a.modLog)
+	threadModel := model.NewThreadModel(a.repo, a.storage.Thread, a.modLog)
+	postModel := model.NewPostModel(a.repo, a.storage.Post, a.modLog)
+	imageModel := model.NewImageModel(a.repo, a.storage.Image)
+
+	imageMap := make(map[string]*model.Image, len(data.Images))
+	for _, imageItem := range data.Images {
+		imageKey, err := uuid.Parse(imageItem.Key)
+		if err != nil {
+			return fmt.Errorf("image %s: %v", imageItem.FilePath, err)
+		}
+		newImage := &model.Image{
+			Key:      model.ImageKey(imageKey),
+			FilePath: imageItem.FilePath,
+		}
+		err = imageModel.PutImage(ctx, newImage)
+		if err != nil {
+			return err
+		}
+		imageMap[imageItem.FilePath] = newImage
+	}
+	imageKey := func(filePath string) *uuid.UUID {
+		imageItem, ok := imageMap[filePath]
+		if !ok {
+			return nil
+		}
+		key := uuid.UUID(imageItem.Key)
+		return &key
+	}
+
+	var threadCount, postCount int
+	for _, boardItem := range data.Boards {
+		boardKey := model.BoardKey(boardItem.Key)
+		err = boardModel.CreateBoard(ctx, model.Board{
+			Key:     boardKey,
+			Name:    boardItem.Name,
+			Captcha: boardItem.Captcha,
+		}, mod)
+		if err != nil {
+			return fmt.Errorf("board /%s/: %v", boardItem.Key, err)
+		}
+
+		for _, threadItem := range boardItem.Threads {
+			newThread := model.Thread{
+				Title:            threadItem.Title,
+				AuthorID:         model.AuthorKey(threadItem.Author),
+				BoardName:        boardKey,
+				CreationDateTime: threadItem.Created,
+				ImageKey:         imageKey(threadItem.Image),
+			}
+
+			var threadID model.ThreadKey
+			postList := threadItem.Posts
+			if len(postList) == 0 {
+				threadID, err = threadModel.PutThread(ctx, newThread)
+			} else {
+				// OP shares the picture of the thread
+				threadID, _, err = threadModel.CreateThreadWithOP(ctx, newThread, model.Post{
+					Author:           model.AuthorKey(postList[0].Author),
+					CreationDateTime: postList[0].Created,
+					Text:             postList[0].Text,
+				}, imageMap[threadItem.Image])
+				postList = postList[1:]
+				postCount++
+			}
+			if err != nil {
+				return fmt.Errorf("thread %d: %v", threadItem.Key, err)
+			}
+
+			for _, postItem := range postList {
+				_, err = postModel.PutPost(ctx, model.Post{
+					Author:           model.AuthorKey(postItem.Author),
+					Thread:           threadID,
+					CreationDateTime: postItem.Created,
+					Text:             postItem.Text,
+					ImageKey:         imageKey(postItem.Image),
+				})
+				if err != nil {
+					return fmt.Errorf("post %d: %v", postItem.Key, err)
+				}
+				postCount++
+			}
+
+			if threadItem.Sticky {
+				err = threadModel.SetSticky(ctx, threadID, true, mod)
+			}
+			if err == nil && threadItem.Locked {
+				err = threadModel.SetLocked(ctx, threadID, true, mod)
+			}
+			if err != nil {
+				return fmt.Errorf("thread %d: %v", threadItem.Key, err)
+			}
+			threadCount++
+		}
+	}
+
+	// cache counters are updated in background by models, so drop the cache to be sure
+	_, err = a.repo.FlushCache(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("imported %d images, %d boards, %d threads, %d posts\n", len(data.Images), len(data.Boards), threadCount, postCount)
+	return nil
+}
@@ -0,0 +1,176 @@
+// Command gochan runs and administers gochan imageboard.
+//
+// Usage:
+//
+//	gochan [config flags] <command> [command flags]
+//
+// Config flags are described by "gochan -h", commands by "gochan help".
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/user"
+
+	"github.com/ilyakaznacheev/gochan"
+	"github.com/ilyakaznacheev/gochan/config"
+	"github.com/ilyakaznacheev/gochan/db"
+	"github.com/ilyakaznacheev/gochan/logging"
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+const usage = `Usage: gochan [config flags] <command> [command flags]
+
+Commands:
+  serve [-storage name]      run http server, applies pending migrations first
+  migrate [up|down|status]   manage database schema
+  board create|list|delete   manage boards
+  ban add|list               manage bans
+  cache flush                drop cached data from redis
+  media gc                   delete image files not referenced by posts
+  export                     dump boards, threads, posts and images as JSON
+  import                     load JSON dump made by export
+
+Run "gochan <command> -h" for command flags.
+`
+
+// errUsage is returned on wrong command line, the usage is printed instead of the error
+var errUsage = errors.New("wrong usage")
+
+// app holds connections shared by commands
+type app struct {
+	conf    config.ConfigData
+	storage *db.Storage
+	repo    *model.RepoHandler
+	modLog  *model.ModActionModel
+}
+
+// newApp opens database and redis connections
+func newApp(conf config.ConfigData) (*app, error) {
+	storage, err := db.OpenStorage(context.Background(), conf)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := model.NewRepoHandler(&conf)
+	if err != nil {
+		storage.Close()
+		return nil, err
+	}
+
+	return &app{
+		conf:    conf,
+		storage: storage,
+		repo:    repo,
+		modLog:  model.NewModActionModel(repo, storage.ModAction),
+	}, nil
+}
+
+func (a *app) close() {
+	if err := a.storage.Close(); err != nil {
+		slog.Error("can't close database", "err", err)
+	}
+	if err := a.repo.Close(); err != nil {
+		slog.Error("can't close redis", "err", err)
+	}
+}
+
+// modInfo returns moderator of command line actions, it is the OS user
+func modInfo(reason string) model.ModInfo {
+	moderator := "cli"
+	if current, err := user.Current(); err == nil {
+		moderator = "cli:" + current.Username
+	}
+	return model.ModInfo{
+		Moderator: moderator,
+		Reason:    reason,
+	}
+}
+
+// command is a subcommand handler, it receives arguments after the command name
+type command func(a *app, args []string) error
+
+var commands = map[string]command{
+	"board":  boardCommand,
+	"ban":    banCommand,
+	"cache":  cacheCommand,
+	"media":  mediaCommand,
+	"export": exportCommand,
+	"import": importCommand,
+}
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) > 1 && os.Args[1] == "help" {
+		fmt.Print(usage)
+		return
+	}
+
+	conf, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = logging.Setup(os.Stderr, conf.Log)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	err = run(conf, args[0], args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if errors.Is(err, errUsage) {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(conf config.ConfigData, name string, args []string) error {
+	switch name {
+	case "serve":
+		flagSet := flag.NewFlagSet("serve", flag.ContinueOnError)
+		flagSet.StringVar(&conf.Storage, "storage", conf.Storage, "database backend: postgres, sqlite or memory, the last two run without redis")
+		if err := flagSet.Parse(args); err != nil {
+			return err
+		}
+		if err := conf.ValidateServe(); err != nil {
+			return err
+		}
+		conf.Print(os.Stdout)
+		return gochan.NewServer(&conf).Run()
+	case "migrate":
+		migrateCommand := "up"
+		if len(args) > 0 {
+			migrateCommand = args[0]
+		}
+		return gochan.NewServer(&conf).Migrate(migrateCommand)
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		return errUsage
+	}
+
+	a, err := newApp(conf)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	return cmd(a, args)
+}
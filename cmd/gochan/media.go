@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// mediaCommand handles "media gc"
+func mediaCommand(a *app, args []string) error {
+	if len(args) == 0 || args[0] != "gc" {
+		return errUsage
+	}
+
+	flagSet := flag.NewFlagSet("media gc", flag.ContinueOnError)
+	dir := flagSet.String("dir", "media/img", "image directory of the server")
+	minAge := flagSet.Duration("min-age", time.Hour, "keep files younger than this, they may be uploads in progress")
+	dryRun := flagSet.Bool("dry-run", false, "only print files to delete")
+	if err := flagSet.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	imageModel := model.NewImageModel(a.repo, a.storage.Image)
+	imageList, err := imageModel.GetList(context.Background())
+	if err != nil {
+		return err
+	}
+	referenced := make(map[string]bool, len(imageList))
+	for _, imageItem := range imageList {
+		referenced[filepath.Base(imageItem.FilePath)] = true
+	}
+
+	entryList, err := os.ReadDir(*dir)
+	if err != nil {
+		return err
+	}
+
+	var deleted, kept int
+	threshold := time.Now().Add(-*minAge)
+	for _, entry := range entryList {
+		if entry.IsDir() || referenced[entry.Name()] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(threshold) {
+			kept++
+			continue
+		}
+
+		filePath := filepath.Join(*dir, entry.Name())
+		if *dryRun {
+			fmt.Println("would delete", filePath)
+		} else if err := os.Remove(filePath); err != nil {
+			return err
+		}
+		deleted++
+	}
+
+	fmt.Printf("%d unreferenced files deleted, %d too young to delete\n", deleted, kept)
+	return nil
+}
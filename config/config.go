@@ -1,40 +1,198 @@
 package config
 
+import "time"
+
 // ConfigData contains app configuration data
 type ConfigData struct {
-	Database ConfigDatabase
-	Redis    ConfigRedis
+	// Storage is a database backend: postgres, sqlite or memory.
+	// SQLite and memory storages need no external services, redis isn't used with them
+	Storage  string         `config:"storage"`
+	HTTP     ConfigHTTP     `config:"http"`
+	Database ConfigDatabase `config:"database"`
+	Redis    ConfigRedis    `config:"redis"`
+	Admin    ConfigAdmin    `config:"admin"`
+	Captcha  ConfigCaptcha  `config:"captcha"`
+	Log      ConfigLog      `config:"log"`
+	Tracing  ConfigTracing  `config:"tracing"`
+	// Dev enables development mode, where templates are reloaded on change
+	Dev bool `config:"dev"`
+	// AssetDir is an optional directory with files overriding embedded
+	// templates, static files and schema, it mirrors their layout
+	AssetDir string `config:"asset_dir"`
+}
+
+// ConfigHTTP contains http server configuration data
+type ConfigHTTP struct {
+	// Address is a tcp address like ":8000" or unix socket path like "unix:/run/gochan.sock"
+	Address string `config:"address"`
+	// ReadTimeout limits time to read the whole request including uploaded image
+	ReadTimeout time.Duration `config:"read_timeout"`
+	// ReadHeaderTimeout limits time to read request headers
+	ReadHeaderTimeout time.Duration `config:"read_header_timeout"`
+	// WriteTimeout limits time to write the response
+	WriteTimeout time.Duration `config:"write_timeout"`
+	// IdleTimeout limits time to wait for the next request on keep-alive connection
+	IdleTimeout time.Duration `config:"idle_timeout"`
+	// MaxHeaderBytes limits request header size
+	MaxHeaderBytes int `config:"max_header_bytes"`
+	// TLSCert and TLSKey are certificate and key file paths, TLS is off if they are empty
+	TLSCert string `config:"tls_cert"`
+	TLSKey  string `config:"tls_key"`
+	// HTTP2 enables HTTP/2, negotiated over TLS or as cleartext h2c without it
+	HTTP2 bool `config:"http2"`
+	// ShutdownTimeout is a time to finish in-flight requests on shutdown, zero means no limit
+	ShutdownTimeout time.Duration `config:"shutdown_timeout"`
+	// ClientIPHeader is a header with client IP set by trusted reverse proxy: X-Forwarded-For or X-Real-IP.
+	// Peer address is used if it is empty, so it has to be set when listening on unix socket behind a proxy
+	ClientIPHeader string `config:"client_ip_header"`
 }
 
 // ConfigDatabase contains database configuration data
 type ConfigDatabase struct {
-	User    string
-	Name    string
-	Pass    string
-	Address string
-	SSL     string
+	// Path is a database file of sqlite storage, ":memory:" keeps it in memory
+	Path    string `config:"path"`
+	User    string `config:"user"`
+	Name    string `config:"name"`
+	Pass    string `config:"pass,secret"`
+	Address string `config:"address"`
+	Port    int    `config:"port"`
+	SSL     string `config:"ssl"`
+	// QueryTimeout limits time of a single model db call, zero means no limit
+	QueryTimeout time.Duration `config:"query_timeout"`
+	// MaxOpenConns and MaxIdleConns limit pool size, zero means no limit for open connections
+	MaxOpenConns int `config:"max_open_conns"`
+	MaxIdleConns int `config:"max_idle_conns"`
+	// ConnMaxLifetime is a time a connection is reused, zero means forever
+	ConnMaxLifetime time.Duration `config:"conn_max_lifetime"`
+	// ConnectTimeout limits a single connection attempt
+	ConnectTimeout time.Duration `config:"connect_timeout"`
+	// ConnectRetries is a number of startup connection retries before giving up
+	ConnectRetries int `config:"connect_retries"`
 }
 
-// ConfigRedis contains redis configuration data
+// ConfigRedis contains redis configuration data.
+// A single node is used by default, Sentinel or Cluster is used if their addresses are set
 type ConfigRedis struct {
-	Address  string
-	Password string
-	DataBase int
+	// Address is a single node address
+	Address  string `config:"address"`
+	Password string `config:"password,secret"`
+	// DataBase is a database number, Cluster supports only 0
+	DataBase int `config:"database"`
+	// MasterName and SentinelAddresses select the master by Sentinel, addresses are comma-separated
+	MasterName        string `config:"master_name"`
+	SentinelAddresses string `config:"sentinel_addresses"`
+	// ClusterAddresses are comma-separated seed nodes of Cluster
+	ClusterAddresses string `config:"cluster_addresses"`
+	// TLS enables TLS, the server is verified by system CAs or by TLSCAFile bundle if it is set
+	TLS           bool   `config:"tls"`
+	TLSCAFile     string `config:"tls_ca_file"`
+	TLSSkipVerify bool   `config:"tls_skip_verify"`
+	// PoolSize is a max number of connections per node, zero keeps client default
+	PoolSize     int           `config:"pool_size"`
+	DialTimeout  time.Duration `config:"dial_timeout"`
+	ReadTimeout  time.Duration `config:"read_timeout"`
+	WriteTimeout time.Duration `config:"write_timeout"`
+	// Timeout limits time of a single cache call, zero means no limit
+	Timeout time.Duration `config:"timeout"`
+	// BreakerThreshold is a number of consecutive failures after which redis is bypassed
+	// and data is read from db only, zero disables the circuit breaker
+	BreakerThreshold int `config:"breaker_threshold"`
+	// BreakerCooldown is a time redis is bypassed before a trial call
+	BreakerCooldown time.Duration `config:"breaker_cooldown"`
+}
+
+// ConfigAdmin contains admin area credentials
+type ConfigAdmin struct {
+	User     string `config:"user"`
+	Password string `config:"password,secret"`
+}
+
+// ConfigCaptcha contains captcha configuration data
+type ConfigCaptcha struct {
+	Length int `config:"length"`
+	// TTL is a time to solve a challenge
+	TTL time.Duration `config:"ttl"`
+	// SkipDuration is a time a poster isn't challenged after solving one
+	SkipDuration time.Duration `config:"skip_duration"`
+}
+
+// ConfigLog contains logging configuration data
+type ConfigLog struct {
+	// Level is a minimal level of logged messages: debug, info, warn or error
+	Level string `config:"level"`
+	// Format is an output format: text or json
+	Format string `config:"format"`
+}
+
+// ConfigTracing contains OpenTelemetry tracing configuration data
+type ConfigTracing struct {
+	// Endpoint is an OTLP/HTTP collector URL like "http://localhost:4318", tracing is off if it is empty
+	Endpoint string `config:"endpoint"`
+	// ServiceName is reported as service.name resource attribute
+	ServiceName string `config:"service_name"`
+	// SampleRatio is a share of traced requests from 0 to 1, incoming sampled traces are always continued
+	SampleRatio float64 `config:"sample_ratio"`
 }
 
+// GetDefaultConfig returns default configuration.
+// It has no passwords, they have to be set by the file, environment or flags
 func GetDefaultConfig() ConfigData {
 	return ConfigData{
+		Storage: "postgres",
+		HTTP: ConfigHTTP{
+			Address:           ":8000",
+			ReadTimeout:       time.Minute,
+			ReadHeaderTimeout: 10 * time.Second,
+			WriteTimeout:      time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			MaxHeaderBytes:    1 << 20,
+			HTTP2:             true,
+			ShutdownTimeout:   30 * time.Second,
+		},
 		Database: ConfigDatabase{
+			Path:    "gochan.db",
 			User:    "gochanuser",
-			Pass:    "gochanpass",
 			Name:    "gochandb",
 			SSL:     "disable",
 			Address: "localhost",
+			Port:    5432,
+
+			QueryTimeout:    5 * time.Second,
+			MaxOpenConns:    20,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnectTimeout:  5 * time.Second,
+			ConnectRetries:  5,
 		},
 		Redis: ConfigRedis{
 			Address:  "localhost:6379",
 			Password: "",
 			DataBase: 0,
+
+			DialTimeout:  5 * time.Second,
+			ReadTimeout:  time.Second,
+			WriteTimeout: time.Second,
+
+			Timeout: time.Second,
+
+			BreakerThreshold: 5,
+			BreakerCooldown:  10 * time.Second,
+		},
+		Admin: ConfigAdmin{
+			User: "admin",
+		},
+		Captcha: ConfigCaptcha{
+			Length:       6,
+			TTL:          10 * time.Minute,
+			SkipDuration: 30 * time.Minute,
+		},
+		Log: ConfigLog{
+			Level:  "info",
+			Format: "text",
+		},
+		Tracing: ConfigTracing{
+			ServiceName: "gochan",
+			SampleRatio: 1,
 		},
 	}
 }
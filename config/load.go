@@ -0,0 +1,320 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// envPrefix is a prefix of environment variables overriding config values,
+	// e.g. GOCHAN_DATABASE_PASS for database.pass
+	envPrefix = "GOCHAN_"
+	// envConfigFile is an environment variable with config file path
+	envConfigFile = "GOCHAN_CONFIG"
+	// redacted replaces secrets in printed config
+	redacted = "******"
+)
+
+// configField is a single config value with its names in every source
+type configField struct {
+	name   string // file key and flag name, e.g. database.query_timeout
+	env    string // environment variable, e.g. GOCHAN_DATABASE_QUERY_TIMEOUT
+	secret bool
+	value  reflect.Value
+}
+
+// fields lists config values in declaration order, sections are flattened with dots
+func (c *ConfigData) fields() []*configField {
+	return collectFields(reflect.ValueOf(c).Elem(), "")
+}
+
+func collectFields(section reflect.Value, prefix string) []*configField {
+	fieldList := make([]*configField, 0)
+	sectionType := section.Type()
+	for idx := 0; idx < sectionType.NumField(); idx++ {
+		tag, ok := sectionType.Field(idx).Tag.Lookup("config")
+		if !ok {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		value := section.Field(idx)
+
+		if value.Kind() == reflect.Struct {
+			fieldList = append(fieldList, collectFields(value, prefix+name+".")...)
+			continue
+		}
+		fieldList = append(fieldList, &configField{
+			name:   prefix + name,
+			env:    envPrefix + strings.ToUpper(strings.ReplaceAll(prefix+name, ".", "_")),
+			secret: options == "secret",
+			value:  value,
+		})
+	}
+	return fieldList
+}
+
+// set parses raw string into the field
+func (f *configField) set(raw string) error {
+	switch f.value.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a duration like 5s or 10m", f.name, raw)
+		}
+		f.value.SetInt(int64(d))
+	case int:
+		i, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not an integer", f.name, raw)
+		}
+		f.value.SetInt(int64(i))
+	case float64:
+		x, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a number", f.name, raw)
+		}
+		f.value.SetFloat(x)
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a boolean", f.name, raw)
+		}
+		f.value.SetBool(b)
+	case string:
+		f.value.SetString(raw)
+	default:
+		return fmt.Errorf("%s: unsupported type %s", f.name, f.value.Type())
+	}
+	return nil
+}
+
+// String returns field value, secrets are redacted
+func (f *configField) String() string {
+	if f.secret && !f.value.IsZero() {
+		return redacted
+	}
+	return fmt.Sprint(f.value.Interface())
+}
+
+// Load builds configuration from defaults, overridden by config file,
+// then by GOCHAN_* environment variables, and then by command-line flags.
+// The config file is set by -config flag or GOCHAN_CONFIG variable, YAML and TOML are supported.
+// Returns validated config and arguments left after flags
+func Load(args []string) (ConfigData, []string, error) {
+	conf := GetDefaultConfig()
+	fieldList := conf.fields()
+
+	flagSet := flag.NewFlagSet("gochan", flag.ContinueOnError)
+	configFile := flagSet.String("config", os.Getenv(envConfigFile), "config file path, YAML or TOML (env "+envConfigFile+")")
+
+	// flags are parsed first to find the config file, and applied last
+	flagValues := make(map[*configField]string)
+	for _, field := range fieldList {
+		field := field
+		flagSet.Func(field.name, "overrides "+field.name+" (env "+field.env+")", func(raw string) error {
+			flagValues[field] = raw
+			return nil
+		})
+	}
+	err := flagSet.Parse(args)
+	if err != nil {
+		return conf, nil, err
+	}
+
+	if *configFile != "" {
+		err = conf.loadFile(*configFile, fieldList)
+		if err != nil {
+			return conf, nil, err
+		}
+	}
+
+	for _, field := range fieldList {
+		if raw, ok := os.LookupEnv(field.env); ok {
+			if err := field.set(raw); err != nil {
+				return conf, nil, fmt.Errorf("env %s: %v", field.env, err)
+			}
+		}
+	}
+
+	for _, field := range fieldList {
+		if raw, ok := flagValues[field]; ok {
+			if err := field.set(raw); err != nil {
+				return conf, nil, fmt.Errorf("flag -%v", err)
+			}
+		}
+	}
+
+	err = conf.Validate()
+	if err != nil {
+		return conf, nil, err
+	}
+	return conf, flagSet.Args(), nil
+}
+
+// loadFile applies values of YAML or TOML config file
+func (c *ConfigData) loadFile(fileName string, fieldList []*configField) error {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("config file: %v", err)
+	}
+
+	raw := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(fileName)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return fmt.Errorf("config file %s: unknown format %q, expected .yaml, .yml or .toml", fileName, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %v", fileName, err)
+	}
+
+	fieldMap := make(map[string]*configField, len(fieldList))
+	for _, field := range fieldList {
+		fieldMap[field.name] = field
+	}
+
+	values := make(map[string]string)
+	flattenValues(raw, "", values)
+	for name, value := range values {
+		field, ok := fieldMap[name]
+		if !ok {
+			return fmt.Errorf("config file %s: unknown key %s", fileName, name)
+		}
+		if err := field.set(value); err != nil {
+			return fmt.Errorf("config file %s: %v", fileName, err)
+		}
+	}
+	return nil
+}
+
+// flattenValues turns nested sections into dotted keys with string values
+func flattenValues(raw map[string]interface{}, prefix string, values map[string]string) {
+	for key, value := range raw {
+		if section, ok := value.(map[string]interface{}); ok {
+			flattenValues(section, prefix+key+".", values)
+			continue
+		}
+		values[prefix+key] = fmt.Sprint(value)
+	}
+}
+
+// Validate checks required fields and value ranges, all problems are reported at once
+func (c *ConfigData) Validate() error {
+	return c.validate(false)
+}
+
+// ValidateServe checks config like Validate, and admin credentials the http server needs as well
+func (c *ConfigData) ValidateServe() error {
+	return c.validate(true)
+}
+
+func (c *ConfigData) validate(serve bool) error {
+	var errs []error
+	required := func(name, value string) {
+		if value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", name))
+		}
+	}
+
+	required("http.address", c.HTTP.Address)
+	switch c.Storage {
+	case "postgres":
+		required("database.user", c.Database.User)
+		required("database.name", c.Database.Name)
+		required("database.address", c.Database.Address)
+	case "sqlite":
+		required("database.path", c.Database.Path)
+	case "memory":
+	default:
+		errs = append(errs, fmt.Errorf("storage %q is not one of postgres, sqlite, memory", c.Storage))
+	}
+	if serve {
+		required("admin.user", c.Admin.User)
+		required("admin.password", c.Admin.Password)
+	}
+
+	switch strings.ToLower(c.HTTP.ClientIPHeader) {
+	case "", "x-forwarded-for", "x-real-ip":
+	default:
+		errs = append(errs, fmt.Errorf("http.client_ip_header %q is not one of X-Forwarded-For, X-Real-IP", c.HTTP.ClientIPHeader))
+	}
+	if (c.HTTP.TLSCert == "") != (c.HTTP.TLSKey == "") {
+		errs = append(errs, errors.New("http.tls_cert and http.tls_key must be set together"))
+	}
+	if c.Storage == "postgres" && (c.Database.Port <= 0 || c.Database.Port > 65535) {
+		errs = append(errs, fmt.Errorf("database.port %d is out of range", c.Database.Port))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 || c.Database.ConnectRetries < 0 {
+		errs = append(errs, errors.New("database pool sizes and retries must not be negative"))
+	}
+	if c.HTTP.MaxHeaderBytes < 0 {
+		errs = append(errs, errors.New("http.max_header_bytes must not be negative"))
+	}
+	if c.Redis.DataBase < 0 {
+		errs = append(errs, errors.New("redis.database must not be negative"))
+	}
+	switch {
+	case c.Redis.ClusterAddresses != "" && (c.Redis.MasterName != "" || c.Redis.SentinelAddresses != ""):
+		errs = append(errs, errors.New("redis.cluster_addresses can't be used with sentinel"))
+	case (c.Redis.MasterName == "") != (c.Redis.SentinelAddresses == ""):
+		errs = append(errs, errors.New("redis.master_name and redis.sentinel_addresses must be set together"))
+	case c.RedisEnabled() && c.Redis.ClusterAddresses == "" && c.Redis.SentinelAddresses == "":
+		required("redis.address", c.Redis.Address)
+	}
+	if c.Redis.PoolSize < 0 || c.Redis.BreakerThreshold < 0 {
+		errs = append(errs, errors.New("redis.pool_size and redis.breaker_threshold must not be negative"))
+	}
+	if c.Captcha.Length <= 0 {
+		errs = append(errs, errors.New("captcha.length must be positive"))
+	}
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log.level %q is not one of debug, info, warn, error", c.Log.Level))
+	}
+	switch c.Log.Format {
+	case "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("log.format %q is not one of text, json", c.Log.Format))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio %v is out of range 0..1", c.Tracing.SampleRatio))
+	}
+	for _, field := range c.fields() {
+		if d, ok := field.value.Interface().(time.Duration); ok && d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", field.name))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// RedisEnabled reports whether redis cache is used.
+// SQLite and memory storages are for development and tests, they run without it
+func (c *ConfigData) RedisEnabled() bool {
+	return c.Storage == "postgres"
+}
+
+// Print writes effective config one value per line, secrets are redacted
+func (c ConfigData) Print(w io.Writer) {
+	for _, field := range c.fields() {
+		fmt.Fprintf(w, "%s = %s\n", field.name, field)
+	}
+}
@@ -0,0 +1,231 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"net"
+
+	"github.com/ilyakaznacheev/gochan/model"
+	"github.com/ilyakaznacheev/gochan/tracing"
+)
+
+// BanDAC is a ban table DAC
+type BanDAC struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// NewBanDAC creates BanDAC instance of postgres database
+func NewBanDAC(db *sql.DB) *BanDAC {
+	return &BanDAC{db, dialectPostgres}
+}
+
+// findBanQueries select active ban, sqlite has neither inet type nor timestamps with time zone
+var findBanQueries = map[dialect]string{
+	dialectPostgres: `SELECT key, ip, author, board, reason, creationdatetime, expirationdatetime
+			FROM ban
+			WHERE (ban.ip >>= $1::inet OR ban.author = $2)
+				AND (ban.board IS NULL OR ban.board = $3)
+				AND (ban.expirationdatetime IS NULL OR ban.expirationdatetime > now())
+			ORDER BY ban.expirationdatetime DESC NULLS FIRST
+			LIMIT 1`,
+	dialectSQLite: `SELECT key, ip, author, board, reason, creationdatetime, expirationdatetime
+			FROM ban
+			WHERE (inet_contains(ban.ip, $1) OR ban.author = $2)
+				AND (ban.board IS NULL OR ban.board = $3)
+				AND (ban.expirationdatetime IS NULL OR julianday(ban.expirationdatetime) > julianday('now'))
+			ORDER BY julianday(ban.expirationdatetime) DESC NULLS FIRST
+			LIMIT 1`,
+}
+
+// GetBanList returns ban list
+func (m *BanDAC) GetBanList(ctx context.Context) ([]*model.Ban, error) {
+	ctx, end := startQuery(ctx, m.dialect, "BanDAC.GetBanList")
+	defer end()
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT key, ip, author, board, reason, creationdatetime, expirationdatetime
+			FROM ban
+			ORDER BY creationdatetime DESC`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	banList := make([]*model.Ban, 0)
+	for rows.Next() {
+		banItem := &model.Ban{}
+		err = rows.Scan(
+			&banItem.Key,
+			&banItem.IP,
+			&banItem.Author,
+			&banItem.Board,
+			&banItem.Reason,
+			&banItem.CreationDateTime,
+			&banItem.ExpirationDateTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		banList = append(banList, banItem)
+	}
+	return banList, rows.Err()
+}
+
+// GetBan returns ban data
+func (m *BanDAC) GetBan(ctx context.Context, banKey model.BanKey) (*model.Ban, error) {
+	ctx, end := startQuery(ctx, m.dialect, "BanDAC.GetBan")
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
+		`SELECT key, ip, author, board, reason, creationdatetime, expirationdatetime
+			FROM ban
+			WHERE key = $1`,
+		banKey,
+	)
+	banItem := &model.Ban{}
+	err := row.Scan(
+		&banItem.Key,
+		&banItem.IP,
+		&banItem.Author,
+		&banItem.Board,
+		&banItem.Reason,
+		&banItem.CreationDateTime,
+		&banItem.ExpirationDateTime,
+	)
+	if err != nil {
+		return nil, notFound(err, "ban", banKey)
+	}
+	return banItem, nil
+}
+
+// FindBan returns active ban matching IP or author on the board.
+// Returns nil ban if nothing matches
+func (m *BanDAC) FindBan(ctx context.Context, ip string, authorKey model.AuthorKey, boardName model.BoardKey) (*model.Ban, error) {
+	ctx, end := startQuery(ctx, m.dialect, "BanDAC.FindBan", tracing.BoardKey.String(string(boardName)))
+	defer end()
+
+	// unknown client IP matches no IP ban
+	clientIP := sql.NullString{String: ip, Valid: net.ParseIP(ip) != nil}
+	row := m.db.QueryRowContext(ctx,
+		findBanQueries[m.dialect],
+		clientIP,
+		authorKey,
+		boardName,
+	)
+	banItem := &model.Ban{}
+	err := row.Scan(
+		&banItem.Key,
+		&banItem.IP,
+		&banItem.Author,
+		&banItem.Board,
+		&banItem.Reason,
+		&banItem.CreationDateTime,
+		&banItem.ExpirationDateTime,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return banItem, nil
+}
+
+// PutBan creates a new ban
+func (m *BanDAC) PutBan(ctx context.Context, newBan model.Ban) (model.BanKey, error) {
+	ctx, end := startQuery(ctx, m.dialect, "BanDAC.PutBan")
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
+		`INSERT INTO ban (ip, author, board, reason, creationdatetime, expirationdatetime) VALUES (
+			$1, $2, $3, $4, $5, $6
+			) RETURNING key;`,
+		newBan.IP,
+		newBan.Author,
+		newBan.Board,
+		newBan.Reason,
+		newBan.CreationDateTime,
+		newBan.ExpirationDateTime,
+	)
+
+	var index model.BanKey
+
+	err := row.Scan(&index)
+	if err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// LiftBan expires a ban immediately
+func (m *BanDAC) LiftBan(ctx context.Context, banKey model.BanKey) error {
+	ctx, end := startQuery(ctx, m.dialect, "BanDAC.LiftBan")
+	defer end()
+
+	res, err := m.db.ExecContext(ctx,
+		`UPDATE ban
+			SET expirationdatetime = now()
+			WHERE key = $1`,
+		banKey,
+	)
+	return affected(res, err, "ban", banKey)
+}
+
+// GetAppealsByBan returns appeals of certain ban
+func (m *BanDAC) GetAppealsByBan(ctx context.Context, banKey model.BanKey) ([]*model.BanAppeal, error) {
+	ctx, end := startQuery(ctx, m.dialect, "BanDAC.GetAppealsByBan")
+	defer end()
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT key, ban, text, creationdatetime
+			FROM ban_appeal
+			WHERE ban = $1
+			ORDER BY creationdatetime`,
+		banKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appealList := make([]*model.BanAppeal, 0)
+	for rows.Next() {
+		appealItem := &model.BanAppeal{}
+		err = rows.Scan(
+			&appealItem.Key,
+			&appealItem.Ban,
+			&appealItem.Text,
+			&appealItem.CreationDateTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		appealList = append(appealList, appealItem)
+	}
+	return appealList, rows.Err()
+}
+
+// PutAppeal creates a new ban appeal
+func (m *BanDAC) PutAppeal(ctx context.Context, newAppeal model.BanAppeal) (model.BanAppealKey, error) {
+	ctx, end := startQuery(ctx, m.dialect, "BanDAC.PutAppeal")
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
+		`INSERT INTO ban_appeal (ban, text, creationdatetime) VALUES (
+			$1, $2, $3
+			) RETURNING key;`,
+		newAppeal.Ban,
+		newAppeal.Text,
+		newAppeal.CreationDateTime,
+	)
+
+	var index model.BanAppealKey
+
+	err := row.Scan(&index)
+	if err != nil {
+		return 0, err
+	}
+	return index, nil
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// notFound translates sql.ErrNoRows into model.ErrNotFound of certain entity
+func notFound(err error, entity string, key interface{}) error {
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%s %v: %w", entity, key, model.ErrNotFound)
+	}
+	return err
+}
+
+// affected returns model.ErrNotFound if the statement didn't change any row
+func affected(res sql.Result, err error, entity string, key interface{}) error {
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return notFound(sql.ErrNoRows, entity, key)
+	}
+	return nil
+}
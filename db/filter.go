@@ -0,0 +1,207 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ilyakaznacheev/gochan/model"
+	"github.com/ilyakaznacheev/gochan/tracing"
+)
+
+// FilterDAC is a filter table DAC
+type FilterDAC struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// NewFilterDAC creates FilterDAC instance
+func NewFilterDAC(db *sql.DB) *FilterDAC {
+	return &FilterDAC{db, dialectPostgres}
+}
+
+// GetFilterList returns filter list
+func (m *FilterDAC) GetFilterList(ctx context.Context) ([]*model.Filter, error) {
+	ctx, end := startQuery(ctx, m.dialect, "FilterDAC.GetFilterList")
+	defer end()
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT key, board, pattern, isregex, action, replacement
+			FROM filter
+			ORDER BY key`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanFilters(rows)
+}
+
+// GetFiltersByBoard returns global filters and filters of certain board
+func (m *FilterDAC) GetFiltersByBoard(ctx context.Context, boardName model.BoardKey) ([]*model.Filter, error) {
+	ctx, end := startQuery(ctx, m.dialect, "FilterDAC.GetFiltersByBoard", tracing.BoardKey.String(string(boardName)))
+	defer end()
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT key, board, pattern, isregex, action, replacement
+			FROM filter
+			WHERE board IS NULL OR board = $1
+			ORDER BY key`,
+		boardName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanFilters(rows)
+}
+
+func scanFilters(rows *sql.Rows) ([]*model.Filter, error) {
+	defer rows.Close()
+
+	filterList := make([]*model.Filter, 0)
+	for rows.Next() {
+		filterItem := &model.Filter{}
+		err := rows.Scan(
+			&filterItem.Key,
+			&filterItem.Board,
+			&filterItem.Pattern,
+			&filterItem.IsRegex,
+			&filterItem.Action,
+			&filterItem.Replacement,
+		)
+		if err != nil {
+			return nil, err
+		}
+		filterList = append(filterList, filterItem)
+	}
+	return filterList, rows.Err()
+}
+
+// GetFilter returns filter data
+func (m *FilterDAC) GetFilter(ctx context.Context, filterKey model.FilterKey) (*model.Filter, error) {
+	ctx, end := startQuery(ctx, m.dialect, "FilterDAC.GetFilter")
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
+		`SELECT key, board, pattern, isregex, action, replacement
+			FROM filter
+			WHERE key = $1`,
+		filterKey,
+	)
+	filterItem := &model.Filter{}
+	err := row.Scan(
+		&filterItem.Key,
+		&filterItem.Board,
+		&filterItem.Pattern,
+		&filterItem.IsRegex,
+		&filterItem.Action,
+		&filterItem.Replacement,
+	)
+	if err != nil {
+		return nil, notFound(err, "filter", filterKey)
+	}
+	return filterItem, nil
+}
+
+// PutFilter creates a new filter
+func (m *FilterDAC) PutFilter(ctx context.Context, newFilter model.Filter) (model.FilterKey, error) {
+	ctx, end := startQuery(ctx, m.dialect, "FilterDAC.PutFilter")
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
+		`INSERT INTO filter (board, pattern, isregex, action, replacement) VALUES (
+			$1, $2, $3, $4, $5
+			) RETURNING key;`,
+		newFilter.Board,
+		newFilter.Pattern,
+		newFilter.IsRegex,
+		newFilter.Action,
+		newFilter.Replacement,
+	)
+
+	var index model.FilterKey
+
+	err := row.Scan(&index)
+	if err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// DeleteFilter removes a filter
+func (m *FilterDAC) DeleteFilter(ctx context.Context, filterKey model.FilterKey) error {
+	ctx, end := startQuery(ctx, m.dialect, "FilterDAC.DeleteFilter")
+	defer end()
+
+	res, err := m.db.ExecContext(ctx,
+		`DELETE FROM filter
+			WHERE key = $1`,
+		filterKey,
+	)
+	return affected(res, err, "filter", filterKey)
+}
+
+// GetFilterMatchList returns latest filter matches
+func (m *FilterDAC) GetFilterMatchList(ctx context.Context, limit int) ([]*model.FilterMatch, error) {
+	ctx, end := startQuery(ctx, m.dialect, "FilterDAC.GetFilterMatchList")
+	defer end()
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT key, filter, action, board, author, thread, post, text, creationdatetime
+			FROM filter_match
+			ORDER BY creationdatetime DESC
+			LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matchList := make([]*model.FilterMatch, 0)
+	for rows.Next() {
+		matchItem := &model.FilterMatch{}
+		err = rows.Scan(
+			&matchItem.Key,
+			&matchItem.Filter,
+			&matchItem.Action,
+			&matchItem.Board,
+			&matchItem.Author,
+			&matchItem.Thread,
+			&matchItem.Post,
+			&matchItem.Text,
+			&matchItem.CreationDateTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		matchList = append(matchList, matchItem)
+	}
+	return matchList, rows.Err()
+}
+
+// PutFilterMatch creates a new filter match record
+func (m *FilterDAC) PutFilterMatch(ctx context.Context, newMatch model.FilterMatch) (model.FilterMatchKey, error) {
+	ctx, end := startQuery(ctx, m.dialect, "FilterDAC.PutFilterMatch")
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
+		`INSERT INTO filter_match (filter, action, board, author, thread, post, text, creationdatetime) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+			) RETURNING key;`,
+		newMatch.Filter,
+		newMatch.Action,
+		newMatch.Board,
+		newMatch.Author,
+		newMatch.Thread,
+		newMatch.Post,
+		newMatch.Text,
+		newMatch.CreationDateTime,
+	)
+
+	var index model.FilterMatchKey
+
+	err := row.Scan(&index)
+	if err != nil {
+		return 0, err
+	}
+	return index, nil
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// BanDAC is a ban table DAC
+type BanDAC struct {
+	store *Store
+}
+
+// NewBanDAC creates BanDAC instance
+func NewBanDAC(store *Store) *BanDAC {
+	return &BanDAC{store}
+}
+
+// GetBanList returns ban list, newest first
+func (m *BanDAC) GetBanList(ctx context.Context) ([]*model.Ban, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	banList := make([]*model.Ban, 0, len(m.store.bans))
+	for _, banItem := range m.store.bans {
+		banItem := banItem
+		banList = append(banList, &banItem)
+	}
+	sort.Slice(banList, func(i, j int) bool {
+		return banList[i].CreationDateTime.After(banList[j].CreationDateTime)
+	})
+	return banList, nil
+}
+
+// GetBan returns ban data
+func (m *BanDAC) GetBan(ctx context.Context, banKey model.BanKey) (*model.Ban, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	banItem, ok := m.store.bans[banKey]
+	if !ok {
+		return nil, notFound("ban", banKey)
+	}
+	return &banItem, nil
+}
+
+// FindBan returns active ban matching IP or author on the board.
+// Permanent ban is preferred, then the longest one.
+// Returns nil ban if nothing matches
+func (m *BanDAC) FindBan(ctx context.Context, ip string, authorKey model.AuthorKey, boardName model.BoardKey) (*model.Ban, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	now := time.Now()
+	var found *model.Ban
+	for _, banItem := range m.store.bans {
+		if !banItem.IsActive(now) || !banItem.Matches(ip, authorKey) {
+			continue
+		}
+		if banItem.Board != nil && *banItem.Board != boardName {
+			continue
+		}
+		if found == nil || longerBan(&banItem, found) {
+			banItem := banItem
+			found = &banItem
+		}
+	}
+	return found, nil
+}
+
+// longerBan checks if ban a expires later than ban b
+func longerBan(a, b *model.Ban) bool {
+	if b.ExpirationDateTime == nil {
+		return false
+	}
+	return a.ExpirationDateTime == nil || a.ExpirationDateTime.After(*b.ExpirationDateTime)
+}
+
+// PutBan creates a new ban
+func (m *BanDAC) PutBan(ctx context.Context, newBan model.Ban) (model.BanKey, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if newBan.Board != nil {
+		if _, ok := m.store.boards[*newBan.Board]; !ok {
+			return 0, missingReference("board", *newBan.Board)
+		}
+	}
+
+	newBan.Key = model.BanKey(m.store.nextKey("ban"))
+	m.store.bans[newBan.Key] = newBan
+	return newBan.Key, nil
+}
+
+// LiftBan expires a ban immediately
+func (m *BanDAC) LiftBan(ctx context.Context, banKey model.BanKey) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	banItem, ok := m.store.bans[banKey]
+	if !ok {
+		return notFound("ban", banKey)
+	}
+	now := time.Now()
+	banItem.ExpirationDateTime = &now
+	m.store.bans[banKey] = banItem
+	return nil
+}
+
+// GetAppealsByBan returns appeals of certain ban
+func (m *BanDAC) GetAppealsByBan(ctx context.Context, banKey model.BanKey) ([]*model.BanAppeal, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	appealList := make([]*model.BanAppeal, 0)
+	for _, appealItem := range m.store.appeals {
+		if appealItem.Ban == banKey {
+			appealItem := appealItem
+			appealList = append(appealList, &appealItem)
+		}
+	}
+	sort.Slice(appealList, func(i, j int) bool {
+		return appealList[i].CreationDateTime.Before(appealList[j].CreationDateTime)
+	})
+	return appealList, nil
+}
+
+// PutAppeal creates a new ban appeal
+func (m *BanDAC) PutAppeal(ctx context.Context, newAppeal model.BanAppeal) (model.BanAppealKey, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.bans[newAppeal.Ban]; !ok {
+		return 0, missingReference("ban", newAppeal.Ban)
+	}
+
+	newAppeal.Key = model.BanAppealKey(m.store.nextKey("ban_appeal"))
+	m.store.appeals[newAppeal.Key] = newAppeal
+	return newAppeal.Key, nil
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// FilterDAC is a filter table DAC
+type FilterDAC struct {
+	store *Store
+}
+
+// NewFilterDAC creates FilterDAC instance
+func NewFilterDAC(store *Store) *FilterDAC {
+	return &FilterDAC{store}
+}
+
+// filters returns filters matching the condition ordered by key
+func (m *FilterDAC) filters(match func(model.Filter) bool) []*model.Filter {
+	filterList := make([]*model.Filter, 0)
+	for _, filterItem := range m.store.filters {
+		if match(filterItem) {
+			filterItem := filterItem
+			filterList = append(filterList, &filterItem)
+		}
+	}
+	sort.Slice(filterList, func(i, j int) bool {
+		return filterList[i].Key < filterList[j].Key
+	})
+	return filterList
+}
+
+// GetFilterList returns filter list
+func (m *FilterDAC) GetFilterList(ctx context.Context) ([]*model.Filter, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	return m.filters(func(model.Filter) bool {
+		return true
+	}), nil
+}
+
+// GetFiltersByBoard returns global filters and filters of certain board
+func (m *FilterDAC) GetFiltersByBoard(ctx context.Context, boardName model.BoardKey) ([]*model.Filter, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	return m.filters(func(filterItem model.Filter) bool {
+		return filterItem.Board == nil || *filterItem.Board == boardName
+	}), nil
+}
+
+// GetFilter returns filter data
+func (m *FilterDAC) GetFilter(ctx context.Context, filterKey model.FilterKey) (*model.Filter, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	filterItem, ok := m.store.filters[filterKey]
+	if !ok {
+		return nil, notFound("filter", filterKey)
+	}
+	return &filterItem, nil
+}
+
+// PutFilter creates a new filter
+func (m *FilterDAC) PutFilter(ctx context.Context, newFilter model.Filter) (model.FilterKey, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if newFilter.Board != nil {
+		if _, ok := m.store.boards[*newFilter.Board]; !ok {
+			return 0, missingReference("board", *newFilter.Board)
+		}
+	}
+
+	newFilter.Key = model.FilterKey(m.store.nextKey("filter"))
+	m.store.filters[newFilter.Key] = newFilter
+	return newFilter.Key, nil
+}
+
+// DeleteFilter removes a filter
+func (m *FilterDAC) DeleteFilter(ctx context.Context, filterKey model.FilterKey) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.filters[filterKey]; !ok {
+		return notFound("filter", filterKey)
+	}
+	delete(m.store.filters, filterKey)
+	return nil
+}
+
+// GetFilterMatchList returns latest filter matches
+func (m *FilterDAC) GetFilterMatchList(ctx context.Context, limit int) ([]*model.FilterMatch, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	matchList := make([]*model.FilterMatch, 0)
+	for _, matchItem := range m.store.matches {
+		matchItem := matchItem
+		matchList = append(matchList, &matchItem)
+	}
+	sort.Slice(matchList, func(i, j int) bool {
+		return matchList[i].CreationDateTime.After(matchList[j].CreationDateTime)
+	})
+	if limit >= 0 && len(matchList) > limit {
+		matchList = matchList[:limit]
+	}
+	return matchList, nil
+}
+
+// PutFilterMatch creates a new filter match record
+func (m *FilterDAC) PutFilterMatch(ctx context.Context, newMatch model.FilterMatch) (model.FilterMatchKey, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	newMatch.Key = model.FilterMatchKey(m.store.nextKey("filter_match"))
+	m.store.matches[newMatch.Key] = newMatch
+	return newMatch.Key, nil
+}
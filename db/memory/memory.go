@@ -0,0 +1,430 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// BoardDAC is a board table DAC
+type BoardDAC struct {
+	store *Store
+}
+
+// NewBoardDAC creates BoardDAC instance
+func NewBoardDAC(store *Store) *BoardDAC {
+	return &BoardDAC{store}
+}
+
+// GetBoardList returns board list
+func (m *BoardDAC) GetBoardList(ctx context.Context) ([]*model.Board, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	boardList := make([]*model.Board, 0, len(m.store.boards))
+	for _, boardItem := range m.store.boards {
+		boardItem := boardItem
+		boardList = append(boardList, &boardItem)
+	}
+	sort.Slice(boardList, func(i, j int) bool {
+		return boardList[i].Key < boardList[j].Key
+	})
+	return boardList, nil
+}
+
+// GetBoard returns board data
+func (m *BoardDAC) GetBoard(ctx context.Context, key model.BoardKey) (*model.Board, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	boardItem, ok := m.store.boards[key]
+	if !ok {
+		return nil, notFound("board", key)
+	}
+	return &boardItem, nil
+}
+
+// PutBoard creates a new board
+func (m *BoardDAC) PutBoard(ctx context.Context, board model.Board) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.boards[board.Key]; ok {
+		return fmt.Errorf("board %s already exists", board.Key)
+	}
+	m.store.boards[board.Key] = board
+	return nil
+}
+
+// UpdateBoard updates board settings
+func (m *BoardDAC) UpdateBoard(ctx context.Context, board model.Board) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.boards[board.Key]; !ok {
+		return notFound("board", board.Key)
+	}
+	m.store.boards[board.Key] = board
+	return nil
+}
+
+// DeleteBoard deletes board with its threads, posts, bans and filters
+func (m *BoardDAC) DeleteBoard(ctx context.Context, key model.BoardKey) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.boards[key]; !ok {
+		return notFound("board", key)
+	}
+	for threadKey, threadItem := range m.store.threads {
+		if threadItem.BoardName == key {
+			m.store.deleteThread(threadKey)
+		}
+	}
+	for banKey, banItem := range m.store.bans {
+		if banItem.Board != nil && *banItem.Board == key {
+			m.store.deleteBan(banKey)
+		}
+	}
+	for filterKey, filterItem := range m.store.filters {
+		if filterItem.Board != nil && *filterItem.Board == key {
+			delete(m.store.filters, filterKey)
+		}
+	}
+	delete(m.store.boards, key)
+	return nil
+}
+
+// ThreadDAC is a thread table DAC
+type ThreadDAC struct {
+	store *Store
+}
+
+// NewThreadDAC creates ThreadDAC instance
+func NewThreadDAC(store *Store) *ThreadDAC {
+	return &ThreadDAC{store}
+}
+
+// thread returns thread as it is selected with image path
+func (m *ThreadDAC) thread(threadItem model.Thread) *model.Thread {
+	threadItem.ImagePath = m.store.imagePath(threadItem.ImageKey)
+	threadItem.ImageKey = nil
+	return &threadItem
+}
+
+// GetTheadsByBoard returns threads of certain board,
+// sticky first and then by the latest post
+func (m *ThreadDAC) GetTheadsByBoard(ctx context.Context, boardName model.BoardKey) ([]*model.Thread, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	lastPost := make(map[model.ThreadKey]int64)
+	for _, postItem := range m.store.posts {
+		if created := postItem.CreationDateTime.UnixNano(); created > lastPost[postItem.Thread] {
+			lastPost[postItem.Thread] = created
+		}
+	}
+
+	threadList := make([]*model.Thread, 0)
+	for _, threadItem := range m.store.threads {
+		if threadItem.BoardName == boardName {
+			threadList = append(threadList, m.thread(threadItem))
+		}
+	}
+	sort.Slice(threadList, func(i, j int) bool {
+		if threadList[i].Sticky != threadList[j].Sticky {
+			return threadList[i].Sticky
+		}
+		lastI, lastJ := lastPost[threadList[i].Key], lastPost[threadList[j].Key]
+		if lastI != lastJ {
+			return lastI > lastJ
+		}
+		return threadList[i].Key > threadList[j].Key
+	})
+	return threadList, nil
+}
+
+// GetThreadsByAuthor returns threads of certain author
+func (m *ThreadDAC) GetThreadsByAuthor(ctx context.Context, authorKey model.AuthorKey) ([]*model.Thread, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	threadList := make([]*model.Thread, 0)
+	for _, threadItem := range m.store.threads {
+		if threadItem.AuthorID == authorKey {
+			threadList = append(threadList, m.thread(threadItem))
+		}
+	}
+	sort.Slice(threadList, func(i, j int) bool {
+		return threadList[i].Key < threadList[j].Key
+	})
+	return threadList, nil
+}
+
+// GetThread returns thread data
+func (m *ThreadDAC) GetThread(ctx context.Context, threadKey model.ThreadKey) (*model.Thread, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	threadItem, ok := m.store.threads[threadKey]
+	if !ok {
+		return nil, notFound("thread", threadKey)
+	}
+	return m.thread(threadItem), nil
+}
+
+// PutThread creates new thread
+func (m *ThreadDAC) PutThread(ctx context.Context, newThread model.Thread) (model.ThreadKey, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	return m.putThread(newThread)
+}
+
+// putThread checks references and saves the thread, it must be called under write lock
+func (m *ThreadDAC) putThread(newThread model.Thread) (model.ThreadKey, error) {
+	if _, ok := m.store.boards[newThread.BoardName]; !ok {
+		return 0, missingReference("board", newThread.BoardName)
+	}
+	if newThread.ImageKey != nil {
+		if _, ok := m.store.images[model.ImageKey(*newThread.ImageKey)]; !ok {
+			return 0, missingReference("image", *newThread.ImageKey)
+		}
+	}
+
+	newThread.Key = model.ThreadKey(m.store.nextKey("thread"))
+	newThread.ImagePath = nil
+	m.store.threads[newThread.Key] = newThread
+	return newThread.Key, nil
+}
+
+// CreateThreadWithOP creates new thread, its opening post and image at once
+func (m *ThreadDAC) CreateThreadWithOP(ctx context.Context, newThread model.Thread, newPost model.Post, newImage *model.Image) (model.ThreadKey, model.PostKey, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.boards[newThread.BoardName]; !ok {
+		return 0, 0, missingReference("board", newThread.BoardName)
+	}
+
+	var imageKey *uuid.UUID
+	if newImage != nil {
+		// the same picture may be already uploaded
+		if _, ok := m.store.images[newImage.Key]; !ok {
+			m.store.images[newImage.Key] = *newImage
+		}
+		key := uuid.UUID(newImage.Key)
+		imageKey = &key
+	}
+
+	newThread.ImageKey = imageKey
+	threadKey, err := m.putThread(newThread)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	newPost.Thread = threadKey
+	newPost.ImageKey = imageKey
+	postKey, err := (&PostDAC{m.store}).putPost(newPost)
+	if err != nil {
+		return 0, 0, err
+	}
+	return threadKey, postKey, nil
+}
+
+// SetThreadFlags updates thread sticky and locked flags
+func (m *ThreadDAC) SetThreadFlags(ctx context.Context, threadKey model.ThreadKey, sticky, locked bool) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	threadItem, ok := m.store.threads[threadKey]
+	if !ok {
+		return notFound("thread", threadKey)
+	}
+	threadItem.Sticky = sticky
+	threadItem.Locked = locked
+	m.store.threads[threadKey] = threadItem
+	return nil
+}
+
+// PostDAC is a post table DAC
+type PostDAC struct {
+	store *Store
+}
+
+// NewPostDAC creates PostDAC instance
+func NewPostDAC(store *Store) *PostDAC {
+	return &PostDAC{store}
+}
+
+// posts returns posts matching the condition in creation order, as they are selected with image path
+func (m *PostDAC) posts(match func(model.Post) bool) []*model.Post {
+	postList := make([]*model.Post, 0)
+	for _, postItem := range m.store.posts {
+		if !match(postItem) {
+			continue
+		}
+		postItem := postItem
+		postItem.ImagePath = m.store.imagePath(postItem.ImageKey)
+		postItem.ImageKey = nil
+		postList = append(postList, &postItem)
+	}
+	sort.Slice(postList, func(i, j int) bool {
+		return postList[i].Key < postList[j].Key
+	})
+	return postList
+}
+
+// GetPostsByThread returns posts of certain thread
+func (m *PostDAC) GetPostsByThread(ctx context.Context, threadKey model.ThreadKey) ([]*model.Post, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	return m.posts(func(postItem model.Post) bool {
+		return postItem.Thread == threadKey
+	}), nil
+}
+
+// GetPostsByAuthor returns posts of certain author
+func (m *PostDAC) GetPostsByAuthor(ctx context.Context, authorKey model.AuthorKey) ([]*model.Post, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	return m.posts(func(postItem model.Post) bool {
+		return postItem.Author == authorKey
+	}), nil
+}
+
+// GetPost returns post data
+func (m *PostDAC) GetPost(ctx context.Context, postKey model.PostKey) (*model.Post, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	postItem, ok := m.store.posts[postKey]
+	if !ok {
+		return nil, notFound("post", postKey)
+	}
+	postItem.ImagePath = m.store.imagePath(postItem.ImageKey)
+	return &postItem, nil
+}
+
+// GetPostBoard returns board of the post thread
+func (m *PostDAC) GetPostBoard(ctx context.Context, postKey model.PostKey) (model.BoardKey, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	postItem, ok := m.store.posts[postKey]
+	if !ok {
+		return "", notFound("post", postKey)
+	}
+	return m.store.threads[postItem.Thread].BoardName, nil
+}
+
+// PutPost creates a new post
+func (m *PostDAC) PutPost(ctx context.Context, newPost model.Post) (model.PostKey, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	return m.putPost(newPost)
+}
+
+// putPost checks references and saves the post, it must be called under write lock
+func (m *PostDAC) putPost(newPost model.Post) (model.PostKey, error) {
+	if _, ok := m.store.threads[newPost.Thread]; !ok {
+		return 0, missingReference("thread", newPost.Thread)
+	}
+	if newPost.ImageKey != nil {
+		if _, ok := m.store.images[model.ImageKey(*newPost.ImageKey)]; !ok {
+			return 0, missingReference("image", *newPost.ImageKey)
+		}
+	}
+
+	newPost.Key = model.PostKey(m.store.nextKey("post"))
+	newPost.ImagePath = nil
+	m.store.posts[newPost.Key] = newPost
+	return newPost.Key, nil
+}
+
+// DeletePost removes a post with its reports
+func (m *PostDAC) DeletePost(ctx context.Context, postKey model.PostKey) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.posts[postKey]; !ok {
+		return notFound("post", postKey)
+	}
+	m.store.deletePost(postKey)
+	return nil
+}
+
+// ImageDAC is a image table DAC
+type ImageDAC struct {
+	store *Store
+}
+
+// NewImageDAC creates ImageDAC instance
+func NewImageDAC(store *Store) *ImageDAC {
+	return &ImageDAC{store}
+}
+
+// IsImageExist checks image existance by key
+func (m *ImageDAC) IsImageExist(ctx context.Context, imageKey model.ImageKey) bool {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	_, ok := m.store.images[imageKey]
+	return ok
+}
+
+// GetImageList returns all images
+func (m *ImageDAC) GetImageList(ctx context.Context) ([]*model.Image, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	imageList := make([]*model.Image, 0, len(m.store.images))
+	for _, imageItem := range m.store.images {
+		imageItem := imageItem
+		imageList = append(imageList, &imageItem)
+	}
+	sort.Slice(imageList, func(i, j int) bool {
+		return imageList[i].FilePath < imageList[j].FilePath
+	})
+	return imageList, nil
+}
+
+// PutImage creates a new image, existing image is kept
+func (m *ImageDAC) PutImage(ctx context.Context, newImage *model.Image) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if _, ok := m.store.images[newImage.Key]; !ok {
+		m.store.images[newImage.Key] = *newImage
+	}
+	return nil
+}
+
+// AuthorDAC is a author table DAC
+type AuthorDAC struct {
+	store *Store
+}
+
+// NewAuthorDAC creates AuthorDAC instance
+func NewAuthorDAC(store *Store) *AuthorDAC {
+	return &AuthorDAC{store}
+}
+
+// GetAuthor returns author info
+func (m *AuthorDAC) GetAuthor(ctx context.Context, authorKey model.AuthorKey) (*model.Author, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	authorItem, ok := m.store.authors[authorKey]
+	if !ok {
+		return nil, notFound("author", authorKey)
+	}
+	return &authorItem, nil
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// ModActionDAC is a mod_action table DAC, the table is append-only
+type ModActionDAC struct {
+	store *Store
+}
+
+// NewModActionDAC creates ModActionDAC instance
+func NewModActionDAC(store *Store) *ModActionDAC {
+	return &ModActionDAC{store}
+}
+
+// GetModActions returns moderation actions matching the filter, newest first
+func (m *ModActionDAC) GetModActions(ctx context.Context, filter model.ModActionFilter) ([]*model.ModAction, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	actionList := make([]*model.ModAction, 0)
+	for _, actionItem := range m.store.modActions {
+		switch {
+		case filter.Moderator != "" && actionItem.Moderator != filter.Moderator,
+			filter.Action != "" && actionItem.Action != filter.Action,
+			filter.Board != "" && (actionItem.Board == nil || *actionItem.Board != filter.Board),
+			filter.Since != nil && actionItem.CreationDateTime.Before(*filter.Since),
+			filter.Until != nil && !actionItem.CreationDateTime.Before(*filter.Until):
+			continue
+		}
+		actionItem := actionItem
+		actionList = append(actionList, &actionItem)
+	}
+	sort.Slice(actionList, func(i, j int) bool {
+		if !actionList[i].CreationDateTime.Equal(actionList[j].CreationDateTime) {
+			return actionList[i].CreationDateTime.After(actionList[j].CreationDateTime)
+		}
+		return actionList[i].Key > actionList[j].Key
+	})
+	if filter.Limit > 0 && len(actionList) > filter.Limit {
+		actionList = actionList[:filter.Limit]
+	}
+	return actionList, nil
+}
+
+// PutModAction appends a moderation action.
+// Missing board and thread are taken from the post and thread if they still exist
+func (m *ModActionDAC) PutModAction(ctx context.Context, newAction model.ModAction) (model.ModActionKey, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if newAction.Thread == nil && newAction.Post != nil {
+		if postItem, ok := m.store.posts[*newAction.Post]; ok {
+			newAction.Thread = &postItem.Thread
+		}
+	}
+	if newAction.Board == nil && newAction.Thread != nil {
+		if threadItem, ok := m.store.threads[*newAction.Thread]; ok {
+			newAction.Board = &threadItem.BoardName
+		}
+	}
+
+	newAction.Key = model.ModActionKey(m.store.nextKey("mod_action"))
+	m.store.modActions = append(m.store.modActions, newAction)
+	return newAction.Key, nil
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// ReportDAC is a report table DAC
+type ReportDAC struct {
+	store *Store
+}
+
+// NewReportDAC creates ReportDAC instance
+func NewReportDAC(store *Store) *ReportDAC {
+	return &ReportDAC{store}
+}
+
+// GetOpenReports returns open reports, most reported first
+func (m *ReportDAC) GetOpenReports(ctx context.Context) ([]*model.Report, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	reportList := make([]*model.Report, 0)
+	for _, reportItem := range m.store.reports {
+		if reportItem.Status == model.ReportOpen {
+			reportItem := reportItem
+			reportList = append(reportList, &reportItem)
+		}
+	}
+	sort.Slice(reportList, func(i, j int) bool {
+		if reportList[i].Count != reportList[j].Count {
+			return reportList[i].Count > reportList[j].Count
+		}
+		return reportList[i].UpdateDateTime.After(reportList[j].UpdateDateTime)
+	})
+	return reportList, nil
+}
+
+// GetReport returns report data
+func (m *ReportDAC) GetReport(ctx context.Context, reportKey model.ReportKey) (*model.Report, error) {
+	m.store.mu.RLock()
+	defer m.store.mu.RUnlock()
+
+	reportItem, ok := m.store.reports[reportKey]
+	if !ok {
+		return nil, notFound("report", reportKey)
+	}
+	return &reportItem, nil
+}
+
+// PutReport creates a new report or increments count of the open report on the same post
+func (m *ReportDAC) PutReport(ctx context.Context, newReport model.Report) (model.ReportKey, error) {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	if newReport.Status == model.ReportOpen {
+		for reportKey, reportItem := range m.store.reports {
+			if reportItem.Post == newReport.Post && reportItem.Status == model.ReportOpen {
+				reportItem.Count += newReport.Count
+				reportItem.UpdateDateTime = newReport.UpdateDateTime
+				m.store.reports[reportKey] = reportItem
+				return reportKey, nil
+			}
+		}
+	}
+	if _, ok := m.store.posts[newReport.Post]; !ok {
+		return 0, missingReference("post", newReport.Post)
+	}
+
+	newReport.Key = model.ReportKey(m.store.nextKey("report"))
+	m.store.reports[newReport.Key] = newReport
+	return newReport.Key, nil
+}
+
+// SetReportStatus updates report status
+func (m *ReportDAC) SetReportStatus(ctx context.Context, reportKey model.ReportKey, status model.ReportStatus) error {
+	m.store.mu.Lock()
+	defer m.store.mu.Unlock()
+
+	reportItem, ok := m.store.reports[reportKey]
+	if !ok {
+		return notFound("report", reportKey)
+	}
+	reportItem.Status = status
+	reportItem.UpdateDateTime = time.Now()
+	m.store.reports[reportKey] = reportItem
+	return nil
+}
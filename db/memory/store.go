@@ -0,0 +1,109 @@
+// Package memory implements DACs keeping data in process memory.
+// It is a storage for development and tests, data is lost on exit.
+// DACs follow behaviour of postgres ones: the same ordering, cascades and not found errors
+package memory
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// Store holds tables of all DACs, they share it to keep references consistent
+type Store struct {
+	mu sync.RWMutex
+
+	boards     map[model.BoardKey]model.Board
+	threads    map[model.ThreadKey]model.Thread
+	posts      map[model.PostKey]model.Post
+	images     map[model.ImageKey]model.Image
+	authors    map[model.AuthorKey]model.Author
+	bans       map[model.BanKey]model.Ban
+	appeals    map[model.BanAppealKey]model.BanAppeal
+	filters    map[model.FilterKey]model.Filter
+	matches    map[model.FilterMatchKey]model.FilterMatch
+	reports    map[model.ReportKey]model.Report
+	modActions []model.ModAction
+
+	// sequences are last keys of tables with serial keys, keys of deleted rows aren't reused
+	sequences map[string]int
+}
+
+// NewStore creates empty store
+func NewStore() *Store {
+	return &Store{
+		boards:    make(map[model.BoardKey]model.Board),
+		threads:   make(map[model.ThreadKey]model.Thread),
+		posts:     make(map[model.PostKey]model.Post),
+		images:    make(map[model.ImageKey]model.Image),
+		authors:   make(map[model.AuthorKey]model.Author),
+		bans:      make(map[model.BanKey]model.Ban),
+		appeals:   make(map[model.BanAppealKey]model.BanAppeal),
+		filters:   make(map[model.FilterKey]model.Filter),
+		matches:   make(map[model.FilterMatchKey]model.FilterMatch),
+		reports:   make(map[model.ReportKey]model.Report),
+		sequences: make(map[string]int),
+	}
+}
+
+// nextKey returns the next serial key of the table, it must be called under write lock
+func (s *Store) nextKey(table string) int {
+	s.sequences[table]++
+	return s.sequences[table]
+}
+
+// imagePath returns file path of the image, or nil if there is no image
+func (s *Store) imagePath(imageKey *uuid.UUID) *string {
+	if imageKey == nil {
+		return nil
+	}
+	imageItem, ok := s.images[model.ImageKey(*imageKey)]
+	if !ok {
+		return nil
+	}
+	filePath := imageItem.FilePath
+	return &filePath
+}
+
+// deleteThread deletes thread with its posts, it must be called under write lock
+func (s *Store) deleteThread(threadKey model.ThreadKey) {
+	for postKey, postItem := range s.posts {
+		if postItem.Thread == threadKey {
+			s.deletePost(postKey)
+		}
+	}
+	delete(s.threads, threadKey)
+}
+
+// deletePost deletes post with its reports, it must be called under write lock
+func (s *Store) deletePost(postKey model.PostKey) {
+	for reportKey, reportItem := range s.reports {
+		if reportItem.Post == postKey {
+			delete(s.reports, reportKey)
+		}
+	}
+	delete(s.posts, postKey)
+}
+
+// deleteBan deletes ban with its appeals, it must be called under write lock
+func (s *Store) deleteBan(banKey model.BanKey) {
+	for appealKey, appealItem := range s.appeals {
+		if appealItem.Ban == banKey {
+			delete(s.appeals, appealKey)
+		}
+	}
+	delete(s.bans, banKey)
+}
+
+// notFound returns model.ErrNotFound of certain entity
+func notFound(entity string, key interface{}) error {
+	return fmt.Errorf("%s %v: %w", entity, key, model.ErrNotFound)
+}
+
+// missingReference returns error of a row referencing missing one, like foreign key violation
+func missingReference(entity string, key interface{}) error {
+	return fmt.Errorf("referenced %s %v doesn't exist", entity, key)
+}
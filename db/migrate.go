@@ -0,0 +1,299 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationLockID is a postgres advisory lock key,
+// that prevents several gochan instances from migrating at once
+const migrationLockID = 7046418
+
+//go:embed migrations/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+// migrationDirs are migration directories of SQL dialects.
+// SQLite migrations mirror postgres ones and must have the same versions.
+// SQLite has no ADD COLUMN IF NOT EXISTS, so unlike postgres they can't adopt existing schema:
+// sqlite databases are supported only if they were created by these migrations
+var migrationDirs = map[dialect]string{
+	dialectPostgres: "migrations",
+	dialectSQLite:   "migrations/sqlite",
+}
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration with its application state
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// LoadMigrations reads embedded postgres migrations sorted by version.
+// Files are named NNNN_name.up.sql and NNNN_name.down.sql.
+// Postgres migrations skip existing objects, so they apply to hand-created schemas
+func LoadMigrations() ([]*Migration, error) {
+	return loadMigrations(dialectPostgres)
+}
+
+// loadMigrations reads embedded migrations of the dialect
+func loadMigrations(sqlDialect dialect) ([]*Migration, error) {
+	dir := migrationDirs[sqlDialect]
+	files, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrationMap := make(map[int]*Migration)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		name := file.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("unexpected migration file %s", name)
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		parts := strings.SplitN(base, "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("wrong migration file name %s", name)
+		}
+		version, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("wrong migration version in %s: %v", name, err)
+		}
+
+		data, err := migrationFiles.ReadFile(path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		migrationItem, ok := migrationMap[version]
+		if !ok {
+			migrationItem = &Migration{Version: version, Name: parts[1]}
+			migrationMap[version] = migrationItem
+		} else if migrationItem.Name != parts[1] {
+			return nil, fmt.Errorf("migration %d has different names %s and %s", version, migrationItem.Name, parts[1])
+		}
+
+		if direction == "up" {
+			migrationItem.Up = string(data)
+		} else {
+			migrationItem.Down = string(data)
+		}
+	}
+
+	migrationList := make([]*Migration, 0, len(migrationMap))
+	for _, migrationItem := range migrationMap {
+		if migrationItem.Up == "" || migrationItem.Down == "" {
+			return nil, fmt.Errorf("migration %d %s must have both up and down files", migrationItem.Version, migrationItem.Name)
+		}
+		migrationList = append(migrationList, migrationItem)
+	}
+	sort.Slice(migrationList, func(i, j int) bool {
+		return migrationList[i].Version < migrationList[j].Version
+	})
+	return migrationList, nil
+}
+
+// Migrator applies embedded migrations and tracks them in schema_migrations table
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []*Migration
+}
+
+// NewMigrator creates Migrator instance of postgres database
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	return newMigrator(db, dialectPostgres)
+}
+
+func newMigrator(db *sql.DB, sqlDialect dialect) (*Migrator, error) {
+	migrationList, err := loadMigrations(sqlDialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db, sqlDialect, migrationList}, nil
+}
+
+// init creates version table if it doesn't exist
+func (m *Migrator) init() error {
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version   integer PRIMARY KEY,
+			name      text NOT NULL,
+			appliedat timestamptz NOT NULL DEFAULT now()
+			)`
+	if m.dialect == dialectSQLite {
+		query = `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version   integer PRIMARY KEY,
+			name      text NOT NULL,
+			appliedat timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`
+	}
+	_, err := m.db.Exec(query)
+	return err
+}
+
+// getApplied returns application time of applied migrations by version
+func (m *Migrator) getApplied(q interface {
+	Query(string, ...interface{}) (*sql.Rows, error)
+}) (map[int]time.Time, error) {
+	rows, err := q.Query(`SELECT version, appliedat FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		err = rows.Scan(&version, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// Status returns all known migrations with their state
+func (m *Migrator) Status() ([]*MigrationStatus, error) {
+	err := m.init()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.getApplied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statusList := make([]*MigrationStatus, 0, len(m.migrations))
+	for _, migrationItem := range m.migrations {
+		statusItem := &MigrationStatus{Migration: *migrationItem}
+		if appliedAt, ok := applied[migrationItem.Version]; ok {
+			statusItem.Applied = true
+			statusItem.AppliedAt = &appliedAt
+		}
+		statusList = append(statusList, statusItem)
+	}
+	return statusList, nil
+}
+
+// Up applies all pending migrations and returns applied ones.
+// Every migration runs in its own transaction
+func (m *Migrator) Up() ([]*Migration, error) {
+	err := m.init()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedList := make([]*Migration, 0)
+	for _, migrationItem := range m.migrations {
+		done, err := m.apply(migrationItem, true)
+		if err != nil {
+			return appliedList, fmt.Errorf("migration %d %s: %v", migrationItem.Version, migrationItem.Name, err)
+		}
+		if done {
+			appliedList = append(appliedList, migrationItem)
+		}
+	}
+	return appliedList, nil
+}
+
+// Down reverts the latest applied migration and returns it,
+// or nil if there is nothing to revert
+func (m *Migrator) Down() (*Migration, error) {
+	err := m.init()
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := len(m.migrations) - 1; idx >= 0; idx-- {
+		migrationItem := m.migrations[idx]
+		done, err := m.apply(migrationItem, false)
+		if err != nil {
+			return nil, fmt.Errorf("migration %d %s: %v", migrationItem.Version, migrationItem.Name, err)
+		}
+		if done {
+			return migrationItem, nil
+		}
+	}
+	return nil, nil
+}
+
+// apply runs migration in given direction if its state allows it,
+// and reports whether anything was done
+func (m *Migrator) apply(migrationItem *Migration, up bool) (bool, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// sqlite database is locked by the write transaction itself
+	if m.dialect == dialectPostgres {
+		_, err = tx.Exec(`SELECT pg_advisory_xact_lock($1)`, migrationLockID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	// state is checked under lock, because another instance could apply it meanwhile
+	applied, err := m.getApplied(tx)
+	if err != nil {
+		return false, err
+	}
+	_, isApplied := applied[migrationItem.Version]
+	if isApplied == up {
+		return false, nil
+	}
+
+	if up {
+		_, err = tx.Exec(migrationItem.Up)
+		if err == nil {
+			_, err = tx.Exec(
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+				migrationItem.Version,
+				migrationItem.Name,
+			)
+		}
+	} else {
+		_, err = tx.Exec(migrationItem.Down)
+		if err == nil {
+			_, err = tx.Exec(
+				`DELETE FROM schema_migrations WHERE version = $1`,
+				migrationItem.Version,
+			)
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
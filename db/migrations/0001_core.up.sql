@@ -0,0 +1,46 @@
+-- objects are created only if missing, so the baseline can be applied
+-- to a database whose schema was created by hand before migrations
+CREATE TABLE IF NOT EXISTS board (
+    key  varchar(16) PRIMARY KEY,
+    name varchar(64) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS author (
+    key varchar(64) PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS image (
+    key      uuid PRIMARY KEY,
+    filepath text NOT NULL
+);
+
+CREATE SEQUENCE IF NOT EXISTS thread_key_seq;
+
+CREATE TABLE IF NOT EXISTS thread (
+    key              integer PRIMARY KEY DEFAULT nextval('thread_key_seq'),
+    title            text NOT NULL,
+    authorid         varchar(64) NOT NULL,
+    boardname        varchar(16) NOT NULL REFERENCES board (key),
+    creationdatetime timestamptz NOT NULL DEFAULT now(),
+    image            uuid REFERENCES image (key)
+);
+
+ALTER SEQUENCE thread_key_seq OWNED BY thread.key;
+
+-- hand-created thread tables took keys from the sequence in inserts, so they have no default
+ALTER TABLE thread ALTER COLUMN key SET DEFAULT nextval('thread_key_seq');
+
+CREATE INDEX IF NOT EXISTS thread_boardname_idx ON thread (boardname);
+CREATE INDEX IF NOT EXISTS thread_authorid_idx ON thread (authorid);
+
+CREATE TABLE IF NOT EXISTS post (
+    key              serial PRIMARY KEY,
+    author           varchar(64) NOT NULL,
+    thread           integer NOT NULL REFERENCES thread (key) ON DELETE CASCADE,
+    creationdatetime timestamptz NOT NULL DEFAULT now(),
+    text             text NOT NULL,
+    image            uuid REFERENCES image (key)
+);
+
+CREATE INDEX IF NOT EXISTS post_thread_idx ON post (thread, creationdatetime);
+CREATE INDEX IF NOT EXISTS post_author_idx ON post (author);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ban_appeal;
+DROP TABLE IF EXISTS ban;
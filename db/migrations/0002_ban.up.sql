@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS ban (
+    key                serial PRIMARY KEY,
+    ip                 inet,
+    author             varchar(64),
+    board              varchar(16) REFERENCES board (key) ON DELETE CASCADE,
+    reason             text NOT NULL,
+    creationdatetime   timestamptz NOT NULL DEFAULT now(),
+    expirationdatetime timestamptz,
+    CHECK (ip IS NOT NULL OR author IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS ban_ip_idx ON ban USING gist (ip inet_ops);
+CREATE INDEX IF NOT EXISTS ban_author_idx ON ban (author);
+
+CREATE TABLE IF NOT EXISTS ban_appeal (
+    key              serial PRIMARY KEY,
+    ban              integer NOT NULL REFERENCES ban (key) ON DELETE CASCADE,
+    text             text NOT NULL,
+    creationdatetime timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS ban_appeal_ban_idx ON ban_appeal (ban);
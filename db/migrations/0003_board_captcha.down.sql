@@ -0,0 +1 @@
+ALTER TABLE board DROP COLUMN IF EXISTS captcha;
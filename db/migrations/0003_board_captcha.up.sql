@@ -0,0 +1 @@
+ALTER TABLE board ADD COLUMN IF NOT EXISTS captcha boolean NOT NULL DEFAULT false;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS filter (
+    key         serial PRIMARY KEY,
+    board       varchar(16) REFERENCES board (key) ON DELETE CASCADE,
+    pattern     text NOT NULL,
+    isregex     boolean NOT NULL DEFAULT false,
+    action      varchar(16) NOT NULL CHECK (action IN ('replace', 'reject', 'moderate')),
+    replacement text NOT NULL DEFAULT ''
+);
+
+-- matches are kept for audit, so thread and post aren't foreign keys
+CREATE TABLE IF NOT EXISTS filter_match (
+    key              serial PRIMARY KEY,
+    filter           integer NOT NULL,
+    action           varchar(16) NOT NULL,
+    board            varchar(16) NOT NULL,
+    author           varchar(64) NOT NULL,
+    thread           integer,
+    post             integer,
+    text             text NOT NULL,
+    creationdatetime timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS filter_match_creationdatetime_idx ON filter_match (creationdatetime);
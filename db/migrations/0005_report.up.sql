@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS report (
+    key              serial PRIMARY KEY,
+    post             integer NOT NULL REFERENCES post (key) ON DELETE CASCADE,
+    reason           text NOT NULL,
+    count            integer NOT NULL DEFAULT 1,
+    status           varchar(16) NOT NULL CHECK (status IN ('open', 'dismissed', 'resolved')),
+    creationdatetime timestamptz NOT NULL DEFAULT now(),
+    updatedatetime   timestamptz NOT NULL DEFAULT now()
+);
+
+-- only one open report per post, repeated reports increase its count
+CREATE UNIQUE INDEX IF NOT EXISTS report_open_post_idx ON report (post) WHERE status = 'open';
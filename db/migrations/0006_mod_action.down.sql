@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS mod_action;
+DROP FUNCTION IF EXISTS mod_action_append_only();
@@ -0,0 +1,27 @@
+-- targets are kept after deletion, so there are no foreign keys
+CREATE TABLE IF NOT EXISTS mod_action (
+    key              serial PRIMARY KEY,
+    moderator        varchar(64) NOT NULL,
+    action           varchar(32) NOT NULL,
+    board            varchar(16),
+    thread           integer,
+    post             integer,
+    image            uuid,
+    ban              integer,
+    reason           text NOT NULL DEFAULT '',
+    creationdatetime timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS mod_action_creationdatetime_idx ON mod_action (creationdatetime);
+CREATE INDEX IF NOT EXISTS mod_action_moderator_idx ON mod_action (moderator);
+
+CREATE OR REPLACE FUNCTION mod_action_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'mod_action is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS mod_action_append_only ON mod_action;
+CREATE TRIGGER mod_action_append_only
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON mod_action
+    FOR EACH STATEMENT EXECUTE PROCEDURE mod_action_append_only();
@@ -0,0 +1,3 @@
+ALTER TABLE thread
+    DROP COLUMN IF EXISTS sticky,
+    DROP COLUMN IF EXISTS locked;
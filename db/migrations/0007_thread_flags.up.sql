@@ -0,0 +1,3 @@
+ALTER TABLE thread
+    ADD COLUMN IF NOT EXISTS sticky boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS locked boolean NOT NULL DEFAULT false;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS post;
+DROP TABLE IF EXISTS thread;
+DROP TABLE IF EXISTS image;
+DROP TABLE IF EXISTS author;
+DROP TABLE IF EXISTS board;
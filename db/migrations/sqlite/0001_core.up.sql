@@ -0,0 +1,40 @@
+-- unlike postgres, sqlite schema is only created by these migrations,
+-- adopting a hand-created sqlite database isn't supported
+CREATE TABLE IF NOT EXISTS board (
+    key  varchar(16) PRIMARY KEY,
+    name varchar(64) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS author (
+    key varchar(64) PRIMARY KEY
+);
+
+CREATE TABLE IF NOT EXISTS image (
+    key      text PRIMARY KEY,
+    filepath text NOT NULL
+);
+
+-- AUTOINCREMENT keeps keys of deleted rows unused, as postgres sequences do
+CREATE TABLE IF NOT EXISTS thread (
+    key              integer PRIMARY KEY AUTOINCREMENT,
+    title            text NOT NULL,
+    authorid         varchar(64) NOT NULL,
+    boardname        varchar(16) NOT NULL REFERENCES board (key),
+    creationdatetime timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    image            text REFERENCES image (key)
+);
+
+CREATE INDEX IF NOT EXISTS thread_boardname_idx ON thread (boardname);
+CREATE INDEX IF NOT EXISTS thread_authorid_idx ON thread (authorid);
+
+CREATE TABLE IF NOT EXISTS post (
+    key              integer PRIMARY KEY AUTOINCREMENT,
+    author           varchar(64) NOT NULL,
+    thread           integer NOT NULL REFERENCES thread (key) ON DELETE CASCADE,
+    creationdatetime timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    text             text NOT NULL,
+    image            text REFERENCES image (key)
+);
+
+CREATE INDEX IF NOT EXISTS post_thread_idx ON post (thread, creationdatetime);
+CREATE INDEX IF NOT EXISTS post_author_idx ON post (author);
@@ -0,0 +1,22 @@
+-- ip is a single IP or CIDR, it is matched by inet_contains function of the driver
+CREATE TABLE IF NOT EXISTS ban (
+    key                integer PRIMARY KEY AUTOINCREMENT,
+    ip                 text,
+    author             varchar(64),
+    board              varchar(16) REFERENCES board (key) ON DELETE CASCADE,
+    reason             text NOT NULL,
+    creationdatetime   timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expirationdatetime timestamp,
+    CHECK (ip IS NOT NULL OR author IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS ban_author_idx ON ban (author);
+
+CREATE TABLE IF NOT EXISTS ban_appeal (
+    key              integer PRIMARY KEY AUTOINCREMENT,
+    ban              integer NOT NULL REFERENCES ban (key) ON DELETE CASCADE,
+    text             text NOT NULL,
+    creationdatetime timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS ban_appeal_ban_idx ON ban_appeal (ban);
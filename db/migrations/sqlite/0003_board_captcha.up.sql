@@ -0,0 +1,2 @@
+-- sqlite has no ADD COLUMN IF NOT EXISTS, existing sqlite schema can't be adopted
+ALTER TABLE board ADD COLUMN captcha boolean NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS filter_match;
+DROP TABLE IF EXISTS filter;
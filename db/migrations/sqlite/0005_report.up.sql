@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS report (
+    key              integer PRIMARY KEY AUTOINCREMENT,
+    post             integer NOT NULL REFERENCES post (key) ON DELETE CASCADE,
+    reason           text NOT NULL,
+    count            integer NOT NULL DEFAULT 1,
+    status           varchar(16) NOT NULL CHECK (status IN ('open', 'dismissed', 'resolved')),
+    creationdatetime timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updatedatetime   timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- only one open report per post, repeated reports increase its count
+CREATE UNIQUE INDEX IF NOT EXISTS report_open_post_idx ON report (post) WHERE status = 'open';
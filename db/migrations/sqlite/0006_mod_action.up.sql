@@ -0,0 +1,28 @@
+-- targets are kept after deletion, so there are no foreign keys
+CREATE TABLE IF NOT EXISTS mod_action (
+    key              integer PRIMARY KEY AUTOINCREMENT,
+    moderator        varchar(64) NOT NULL,
+    action           varchar(32) NOT NULL,
+    board            varchar(16),
+    thread           integer,
+    post             integer,
+    image            text,
+    ban              integer,
+    reason           text NOT NULL DEFAULT '',
+    creationdatetime timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS mod_action_creationdatetime_idx ON mod_action (creationdatetime);
+CREATE INDEX IF NOT EXISTS mod_action_moderator_idx ON mod_action (moderator);
+
+CREATE TRIGGER IF NOT EXISTS mod_action_no_update
+    BEFORE UPDATE ON mod_action
+BEGIN
+    SELECT RAISE(ABORT, 'mod_action is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS mod_action_no_delete
+    BEFORE DELETE ON mod_action
+BEGIN
+    SELECT RAISE(ABORT, 'mod_action is append-only');
+END;
@@ -0,0 +1,2 @@
+ALTER TABLE thread DROP COLUMN sticky;
+ALTER TABLE thread DROP COLUMN locked;
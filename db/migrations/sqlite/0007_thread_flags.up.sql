@@ -0,0 +1,3 @@
+-- sqlite has no ADD COLUMN IF NOT EXISTS, existing sqlite schema can't be adopted
+ALTER TABLE thread ADD COLUMN sticky boolean NOT NULL DEFAULT false;
+ALTER TABLE thread ADD COLUMN locked boolean NOT NULL DEFAULT false;
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// ModActionDAC is a mod_action table DAC
+type ModActionDAC struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// NewModActionDAC creates ModActionDAC instance
+func NewModActionDAC(db *sql.DB) *ModActionDAC {
+	return &ModActionDAC{db, dialectPostgres}
+}
+
+// GetModActions returns moderation actions matching the filter, newest first
+func (m *ModActionDAC) GetModActions(ctx context.Context, filter model.ModActionFilter) ([]*model.ModAction, error) {
+	ctx, end := startQuery(ctx, m.dialect, "ModActionDAC.GetModActions")
+	defer end()
+
+	var (
+		where []string
+		args  []interface{}
+	)
+	addCond := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Moderator != "" {
+		addCond("moderator = $%d", filter.Moderator)
+	}
+	if filter.Action != "" {
+		addCond("action = $%d", filter.Action)
+	}
+	if filter.Board != "" {
+		addCond("board = $%d", filter.Board)
+	}
+	if filter.Since != nil {
+		addCond("creationdatetime >= $%d", *filter.Since)
+	}
+	if filter.Until != nil {
+		addCond("creationdatetime < $%d", *filter.Until)
+	}
+
+	query := `SELECT key, moderator, action, board, thread, post, image, ban, reason, creationdatetime
+			FROM mod_action`
+	if len(where) > 0 {
+		query += `
+			WHERE ` + strings.Join(where, " AND ")
+	}
+	query += `
+			ORDER BY creationdatetime DESC, key DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(`
+			LIMIT $%d`, len(args))
+	}
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actionList := make([]*model.ModAction, 0)
+	for rows.Next() {
+		actionItem := &model.ModAction{}
+		err = rows.Scan(
+			&actionItem.Key,
+			&actionItem.Moderator,
+			&actionItem.Action,
+			&actionItem.Board,
+			&actionItem.Thread,
+			&actionItem.Post,
+			&actionItem.Image,
+			&actionItem.Ban,
+			&actionItem.Reason,
+			&actionItem.CreationDateTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		actionList = append(actionList, actionItem)
+	}
+	return actionList, rows.Err()
+}
+
+// PutModAction appends a moderation action.
+// Missing board and thread are taken from the post and thread if they still exist
+func (m *ModActionDAC) PutModAction(ctx context.Context, newAction model.ModAction) (model.ModActionKey, error) {
+	ctx, end := startQuery(ctx, m.dialect, "ModActionDAC.PutModAction")
+	defer end()
+
+	var imageKeyStr *string
+	if newAction.Image != nil {
+		strval := newAction.Image.String()
+		imageKeyStr = &strval
+	}
+	row := m.db.QueryRowContext(ctx,
+		`WITH target AS (
+			SELECT COALESCE(CAST($3 AS integer), (SELECT thread FROM post WHERE key = $4)) AS thread
+			)
+			INSERT INTO mod_action (moderator, action, board, thread, post, image, ban, reason, creationdatetime)
+			SELECT $1, $2,
+				COALESCE($5, (SELECT boardname FROM thread WHERE thread.key = target.thread)),
+				target.thread,
+				$4, $6, $7, $8, $9
+			FROM target
+			RETURNING key;`,
+		newAction.Moderator,
+		newAction.Action,
+		newAction.Thread,
+		newAction.Post,
+		newAction.Board,
+		imageKeyStr,
+		newAction.Ban,
+		newAction.Reason,
+		newAction.CreationDateTime,
+	)
+
+	var index model.ModActionKey
+
+	err := row.Scan(&index)
+	if err != nil {
+		return 0, err
+	}
+	return index, nil
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ilyakaznacheev/gochan/config"
+)
+
+const (
+	// connectBackoffStart is a delay before the second connection attempt
+	connectBackoffStart = 500 * time.Millisecond
+	// connectBackoffMax limits the delay between connection attempts
+	connectBackoffMax = 10 * time.Second
+)
+
+// DSN builds postgres connection URL with escaped credentials.
+// Address may be a host name, or a unix socket directory starting with "/"
+func DSN(conf config.ConfigDatabase) string {
+	query := url.Values{}
+	query.Set("sslmode", conf.SSL)
+	if conf.ConnectTimeout > 0 {
+		// postgres accepts whole seconds only, at least 2
+		seconds := int((conf.ConnectTimeout + time.Second - 1) / time.Second)
+		if seconds < 2 {
+			seconds = 2
+		}
+		query.Set("connect_timeout", strconv.Itoa(seconds))
+	}
+
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(conf.User, conf.Pass),
+		Path:   "/" + conf.Name,
+	}
+	port := strconv.Itoa(conf.Port)
+	if strings.HasPrefix(conf.Address, "/") {
+		query.Set("host", conf.Address)
+		query.Set("port", port)
+	} else {
+		dsn.Host = net.JoinHostPort(conf.Address, port)
+	}
+	dsn.RawQuery = query.Encode()
+
+	return dsn.String()
+}
+
+// Open creates postgres connection pool configured by the pool settings.
+// It doesn't connect, use Connect to wait for the database
+func Open(conf config.ConfigDatabase) (*sql.DB, error) {
+	dbConn, err := sql.Open("postgres", DSN(conf))
+	if err != nil {
+		return nil, err
+	}
+
+	dbConn.SetMaxOpenConns(conf.MaxOpenConns)
+	dbConn.SetMaxIdleConns(conf.MaxIdleConns)
+	dbConn.SetConnMaxLifetime(conf.ConnMaxLifetime)
+	return dbConn, nil
+}
+
+// Connect creates postgres connection pool and pings the database until it answers.
+// Failed attempts are retried with exponential backoff up to ConnectRetries times
+func Connect(ctx context.Context, conf config.ConfigDatabase) (*sql.DB, error) {
+	dbConn, err := Open(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := connectBackoffStart
+	for attempt := 0; ; attempt++ {
+		err = ping(ctx, dbConn, conf.ConnectTimeout)
+		if err == nil {
+			return dbConn, nil
+		}
+		if attempt >= conf.ConnectRetries {
+			break
+		}
+
+		slog.WarnContext(ctx, "database is not available", "address", conf.Address, "retry_in", backoff.String(), "err", err)
+		select {
+		case <-ctx.Done():
+			dbConn.Close()
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > connectBackoffMax {
+			backoff = connectBackoffMax
+		}
+	}
+
+	dbConn.Close()
+	return nil, err
+}
+
+// ping checks database connection within the timeout, zero timeout means no limit
+func ping(ctx context.Context, dbConn *sql.DB, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return dbConn.PingContext(ctx)
+}
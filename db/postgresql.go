@@ -1,46 +1,58 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"errors"
-	"log"
+	"log/slog"
 
 	"github.com/google/uuid"
 	_ "github.com/lib/pq" // use Postgres driver
 
 	"github.com/ilyakaznacheev/gochan/model"
+	"github.com/ilyakaznacheev/gochan/tracing"
 )
 
 // BoardDAC is a board table DAC
 type BoardDAC struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect dialect
 }
 
 // NewBoardDAC creates BoardDAC instance
 func NewBoardDAC(db *sql.DB) *BoardDAC {
-	return &BoardDAC{db}
+	return &BoardDAC{db, dialectPostgres}
 }
 
 // GetBoardList returns board list
-func (m *BoardDAC) GetBoardList() ([]*model.Board, error) {
-	rows, err := m.db.Query(`SELECT key, name FROM board`)
+func (m *BoardDAC) GetBoardList(ctx context.Context) ([]*model.Board, error) {
+	ctx, end := startQuery(ctx, m.dialect, "BoardDAC.GetBoardList")
+	defer end()
+
+	rows, err := m.db.QueryContext(ctx, `SELECT key, name, captcha FROM board`)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
 	boardList := make([]*model.Board, 0)
 	for rows.Next() {
 		boardItem := &model.Board{}
-		err = rows.Scan(&boardItem.Key, &boardItem.Name)
+		err = rows.Scan(&boardItem.Key, &boardItem.Name, &boardItem.Captcha)
+		if err != nil {
+			return nil, err
+		}
 		boardList = append(boardList, boardItem)
 	}
-	rows.Close()
-	return boardList, nil
+	return boardList, rows.Err()
 }
 
 // GetBoard returns board data
-func (m *BoardDAC) GetBoard(key model.BoardKey) (*model.Board, error) {
-	row := m.db.QueryRow(
-		`SELECT key, name 
+func (m *BoardDAC) GetBoard(ctx context.Context, key model.BoardKey) (*model.Board, error) {
+	ctx, end := startQuery(ctx, m.dialect, "BoardDAC.GetBoard", tracing.BoardKey.String(string(key)))
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
+		`SELECT key, name, captcha
 			FROM board
 			WHERE key = $1`,
 		key,
@@ -50,34 +62,100 @@ func (m *BoardDAC) GetBoard(key model.BoardKey) (*model.Board, error) {
 	err := row.Scan(
 		&boardItem.Key,
 		&boardItem.Name,
+		&boardItem.Captcha,
 	)
+	if err != nil {
+		return nil, notFound(err, "board", key)
+	}
+	return boardItem, nil
+}
 
-	return boardItem, err
+// PutBoard creates a new board
+func (m *BoardDAC) PutBoard(ctx context.Context, board model.Board) error {
+	ctx, end := startQuery(ctx, m.dialect, "BoardDAC.PutBoard", tracing.BoardKey.String(string(board.Key)))
+	defer end()
+
+	_, err := m.db.ExecContext(ctx,
+		`INSERT INTO board (key, name, captcha) VALUES (
+			$1, $2, $3
+			)`,
+		board.Key,
+		board.Name,
+		board.Captcha,
+	)
+	return err
+}
+
+// UpdateBoard updates board settings
+func (m *BoardDAC) UpdateBoard(ctx context.Context, board model.Board) error {
+	ctx, end := startQuery(ctx, m.dialect, "BoardDAC.UpdateBoard", tracing.BoardKey.String(string(board.Key)))
+	defer end()
+
+	res, err := m.db.ExecContext(ctx,
+		`UPDATE board
+			SET name = $2, captcha = $3
+			WHERE key = $1`,
+		board.Key,
+		board.Name,
+		board.Captcha,
+	)
+	return affected(res, err, "board", board.Key)
+}
+
+// DeleteBoard deletes board with its threads, posts are deleted by cascade
+func (m *BoardDAC) DeleteBoard(ctx context.Context, key model.BoardKey) error {
+	ctx, end := startQuery(ctx, m.dialect, "BoardDAC.DeleteBoard", tracing.BoardKey.String(string(key)))
+	defer end()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM thread WHERE boardname = $1`, key)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM board WHERE key = $1`, key)
+	err = affected(res, err, "board", key)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // ThreadDAC is a thread table DAC
 type ThreadDAC struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect dialect
 }
 
 // NewThreadDAC creates ThreadDAC instance
 func NewThreadDAC(db *sql.DB) *ThreadDAC {
-	return &ThreadDAC{db}
+	return &ThreadDAC{db, dialectPostgres}
 }
 
 // GetTheadsByBoard returns threads of certain board
-func (m *ThreadDAC) GetTheadsByBoard(boardName model.BoardKey) ([]*model.Thread, error) {
-	rows, err := m.db.Query(
-		`SELECT thread.key, thread.title, thread.authorid, thread.boardname, thread.creationdatetime, image.filepath
+func (m *ThreadDAC) GetTheadsByBoard(ctx context.Context, boardName model.BoardKey) ([]*model.Thread, error) {
+	ctx, end := startQuery(ctx, m.dialect, "ThreadDAC.GetTheadsByBoard", tracing.BoardKey.String(string(boardName)))
+	defer end()
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT thread.key, thread.title, thread.authorid, thread.boardname, thread.creationdatetime, image.filepath, thread.sticky, thread.locked
 			FROM thread
 				LEFT OUTER JOIN image ON
 				(thread.image = image.key)
-			WHERE thread.boardname = $1`,
+			WHERE thread.boardname = $1
+			ORDER BY thread.sticky DESC,
+				(SELECT max(post.creationdatetime) FROM post WHERE post.thread = thread.key) DESC NULLS LAST`,
 		boardName,
 	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	threadList := make([]*model.Thread, 0)
 	for rows.Next() {
@@ -89,21 +167,24 @@ func (m *ThreadDAC) GetTheadsByBoard(boardName model.BoardKey) ([]*model.Thread,
 			&threadItem.BoardName,
 			&threadItem.CreationDateTime,
 			&threadItem.ImagePath,
+			&threadItem.Sticky,
+			&threadItem.Locked,
 		)
+		if err != nil {
+			return nil, err
+		}
 		threadList = append(threadList, threadItem)
 	}
-	rows.Close()
-
-	if len(threadList) == 0 {
-		return nil, errors.New("no threads found with board name " + string(boardName))
-	}
-	return threadList, nil
+	return threadList, rows.Err()
 }
 
 // GetThreadsByAuthor returns threads of certain author
-func (m *ThreadDAC) GetThreadsByAuthor(authorKey model.AuthorKey) ([]*model.Thread, error) {
-	rows, err := m.db.Query(
-		`SELECT thread.key, thread.title, thread.authorid, thread.boardname, thread.creationdatetime, image.filepath
+func (m *ThreadDAC) GetThreadsByAuthor(ctx context.Context, authorKey model.AuthorKey) ([]*model.Thread, error) {
+	ctx, end := startQuery(ctx, m.dialect, "ThreadDAC.GetThreadsByAuthor")
+	defer end()
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT thread.key, thread.title, thread.authorid, thread.boardname, thread.creationdatetime, image.filepath, thread.sticky, thread.locked
 			FROM thread
 				LEFT OUTER JOIN image ON
 				(thread.image = image.key)
@@ -113,6 +194,7 @@ func (m *ThreadDAC) GetThreadsByAuthor(authorKey model.AuthorKey) ([]*model.Thre
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	threadList := make([]*model.Thread, 0)
 	for rows.Next() {
@@ -124,21 +206,24 @@ func (m *ThreadDAC) GetThreadsByAuthor(authorKey model.AuthorKey) ([]*model.Thre
 			&threadItem.BoardName,
 			&threadItem.CreationDateTime,
 			&threadItem.ImagePath,
+			&threadItem.Sticky,
+			&threadItem.Locked,
 		)
+		if err != nil {
+			return nil, err
+		}
 		threadList = append(threadList, threadItem)
 	}
-	rows.Close()
-
-	if len(threadList) == 0 {
-		return nil, errors.New("no threads found with author ID " + string(authorKey))
-	}
-	return threadList, nil
+	return threadList, rows.Err()
 }
 
 // GetThread returns thread data
-func (m *ThreadDAC) GetThread(threadKey model.ThreadKey) (*model.Thread, error) {
-	row := m.db.QueryRow(
-		`SELECT thread.key, thread.title, thread.authorid, thread.boardname, thread.creationdatetime, image.filepath
+func (m *ThreadDAC) GetThread(ctx context.Context, threadKey model.ThreadKey) (*model.Thread, error) {
+	ctx, end := startQuery(ctx, m.dialect, "ThreadDAC.GetThread", tracing.ThreadKey.Int(int(threadKey)))
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
+		`SELECT thread.key, thread.title, thread.authorid, thread.boardname, thread.creationdatetime, image.filepath, thread.sticky, thread.locked
 			FROM thread
 				LEFT OUTER JOIN image ON
 				(thread.image = image.key)
@@ -153,23 +238,27 @@ func (m *ThreadDAC) GetThread(threadKey model.ThreadKey) (*model.Thread, error)
 		&threadItem.BoardName,
 		&threadItem.CreationDateTime,
 		&threadItem.ImagePath,
+		&threadItem.Sticky,
+		&threadItem.Locked,
 	)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "thread", threadKey)
 	}
 	return threadItem, nil
 }
 
 // PutThread creates new thread
-func (m *ThreadDAC) PutThread(newThread model.Thread) (model.ThreadKey, error) {
+func (m *ThreadDAC) PutThread(ctx context.Context, newThread model.Thread) (model.ThreadKey, error) {
+	ctx, end := startQuery(ctx, m.dialect, "ThreadDAC.PutThread", tracing.BoardKey.String(string(newThread.BoardName)))
+	defer end()
+
 	var imageKeyStr *string
 	if newThread.ImageKey != nil {
 		strval := newThread.ImageKey.String()
 		imageKeyStr = &strval
 	}
-	row := m.db.QueryRow(
-		`INSERT INTO thread (key, title, authorid, boardname, creationdatetime, image ) VALUES (
-			nextval('thread_key_seq'),
+	row := m.db.QueryRowContext(ctx,
+		`INSERT INTO thread (title, authorid, boardname, creationdatetime, image) VALUES (
 			$1, $2, $3, $4, $5
 			) RETURNING key;`,
 		newThread.Title,
@@ -188,19 +277,106 @@ func (m *ThreadDAC) PutThread(newThread model.Thread) (model.ThreadKey, error) {
 	return index, nil
 }
 
+// CreateThreadWithOP creates new thread, its opening post and image in one transaction
+func (m *ThreadDAC) CreateThreadWithOP(ctx context.Context, newThread model.Thread, newPost model.Post, newImage *model.Image) (model.ThreadKey, model.PostKey, error) {
+	ctx, end := startQuery(ctx, m.dialect, "ThreadDAC.CreateThreadWithOP", tracing.BoardKey.String(string(newThread.BoardName)))
+	defer end()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	var imageKeyStr *string
+	if newImage != nil {
+		strval := uuid.UUID(newImage.Key).String()
+		imageKeyStr = &strval
+
+		// the same picture may be already uploaded
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO image (key, filepath) VALUES (
+				$1, $2
+				)
+				ON CONFLICT (key) DO NOTHING`,
+			strval,
+			newImage.FilePath,
+		)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	var threadIndex model.ThreadKey
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO thread (title, authorid, boardname, creationdatetime, image) VALUES (
+			$1, $2, $3, $4, $5
+			) RETURNING key;`,
+		newThread.Title,
+		newThread.AuthorID,
+		newThread.BoardName,
+		newThread.CreationDateTime,
+		imageKeyStr,
+	).Scan(&threadIndex)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var postIndex model.PostKey
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO post (author, thread, creationdatetime, text, image) VALUES (
+			$1, $2, $3, $4, $5
+			) RETURNING key;`,
+		newPost.Author,
+		threadIndex,
+		newPost.CreationDateTime,
+		newPost.Text,
+		imageKeyStr,
+	).Scan(&postIndex)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, 0, err
+	}
+	return threadIndex, postIndex, nil
+}
+
+// SetThreadFlags updates thread sticky and locked flags
+func (m *ThreadDAC) SetThreadFlags(ctx context.Context, threadKey model.ThreadKey, sticky, locked bool) error {
+	ctx, end := startQuery(ctx, m.dialect, "ThreadDAC.SetThreadFlags", tracing.ThreadKey.Int(int(threadKey)))
+	defer end()
+
+	res, err := m.db.ExecContext(ctx,
+		`UPDATE thread
+			SET sticky = $2, locked = $3
+			WHERE key = $1`,
+		threadKey,
+		sticky,
+		locked,
+	)
+	return affected(res, err, "thread", threadKey)
+}
+
 // PostDAC is a post table DAC
 type PostDAC struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect dialect
 }
 
 // NewPostDAC creates PostDAC instance
 func NewPostDAC(db *sql.DB) *PostDAC {
-	return &PostDAC{db}
+	return &PostDAC{db, dialectPostgres}
 }
 
 // GetPostsByThread returns posts of certain thread
-func (m *PostDAC) GetPostsByThread(threadKey model.ThreadKey) ([]*model.Post, error) {
-	rows, err := m.db.Query(
+func (m *PostDAC) GetPostsByThread(ctx context.Context, threadKey model.ThreadKey) ([]*model.Post, error) {
+	ctx, end := startQuery(ctx, m.dialect, "PostDAC.GetPostsByThread", tracing.ThreadKey.Int(int(threadKey)))
+	defer end()
+
+	rows, err := m.db.QueryContext(ctx,
 		`SELECT post.key, post.author, post.thread, post.creationdatetime, post.text, image.filepath
 			FROM post
 				LEFT OUTER JOIN image ON
@@ -211,6 +387,7 @@ func (m *PostDAC) GetPostsByThread(threadKey model.ThreadKey) ([]*model.Post, er
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	postList := make([]*model.Post, 0)
 	for rows.Next() {
@@ -223,20 +400,21 @@ func (m *PostDAC) GetPostsByThread(threadKey model.ThreadKey) ([]*model.Post, er
 			&postItem.Text,
 			&postItem.ImagePath,
 		)
+		if err != nil {
+			return nil, err
+		}
 		postList = append(postList, postItem)
 	}
-	rows.Close()
-
-	if len(postList) == 0 {
-		return nil, errors.New("no posts found with Thread ID" + threadKey.String())
-	}
-	return postList, nil
+	return postList, rows.Err()
 
 }
 
 // GetPostsByAuthor returns posts of certain author
-func (m *PostDAC) GetPostsByAuthor(authorKey model.AuthorKey) ([]*model.Post, error) {
-	rows, err := m.db.Query(
+func (m *PostDAC) GetPostsByAuthor(ctx context.Context, authorKey model.AuthorKey) ([]*model.Post, error) {
+	ctx, end := startQuery(ctx, m.dialect, "PostDAC.GetPostsByAuthor")
+	defer end()
+
+	rows, err := m.db.QueryContext(ctx,
 		`SELECT post.key, post.author, post.thread, post.creationdatetime, post.text, image.filepath
 			FROM post
 				LEFT OUTER JOIN image ON
@@ -247,6 +425,7 @@ func (m *PostDAC) GetPostsByAuthor(authorKey model.AuthorKey) ([]*model.Post, er
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	postList := make([]*model.Post, 0)
 	for rows.Next() {
@@ -259,20 +438,21 @@ func (m *PostDAC) GetPostsByAuthor(authorKey model.AuthorKey) ([]*model.Post, er
 			&postItem.Text,
 			&postItem.ImagePath,
 		)
+		if err != nil {
+			return nil, err
+		}
 		postList = append(postList, postItem)
 	}
-	rows.Close()
-
-	if len(postList) == 0 {
-		return nil, errors.New("no posts found with Author ID " + string(authorKey))
-	}
-	return postList, nil
+	return postList, rows.Err()
 }
 
 // GetPost returns post data
-func (m *PostDAC) GetPost(postKey model.PostKey) (*model.Post, error) {
-	row := m.db.QueryRow(
-		`SELECT post.key, post.author, post.thread, post.creationdatetime, post.text, image.filepath
+func (m *PostDAC) GetPost(ctx context.Context, postKey model.PostKey) (*model.Post, error) {
+	ctx, end := startQuery(ctx, m.dialect, "PostDAC.GetPost", tracing.PostKey.Int(int(postKey)))
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
+		`SELECT post.key, post.author, post.thread, post.creationdatetime, post.text, post.image, image.filepath
 			FROM post
 				LEFT OUTER JOIN image ON
 				(post.image = image.key)
@@ -286,22 +466,47 @@ func (m *PostDAC) GetPost(postKey model.PostKey) (*model.Post, error) {
 		&postItem.Thread,
 		&postItem.CreationDateTime,
 		&postItem.Text,
+		&postItem.ImageKey,
 		&postItem.ImagePath,
 	)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "post", postKey)
 	}
 	return postItem, nil
 }
 
+// GetPostBoard returns board of the post thread
+func (m *PostDAC) GetPostBoard(ctx context.Context, postKey model.PostKey) (model.BoardKey, error) {
+	ctx, end := startQuery(ctx, m.dialect, "PostDAC.GetPostBoard", tracing.PostKey.Int(int(postKey)))
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
+		`SELECT thread.boardname
+			FROM post
+				INNER JOIN thread ON
+				(post.thread = thread.key)
+			WHERE post.key = $1`,
+		postKey,
+	)
+	var boardName model.BoardKey
+	err := row.Scan(&boardName)
+	if err != nil {
+		return "", notFound(err, "post", postKey)
+	}
+	return boardName, nil
+}
+
 // PutPost creates a new post
-func (m *PostDAC) PutPost(newPost model.Post) (model.PostKey, error) {
+func (m *PostDAC) PutPost(ctx context.Context, newPost model.Post) (model.PostKey, error) {
+	ctx, end := startQuery(ctx, m.dialect, "PostDAC.PutPost", tracing.ThreadKey.Int(int(newPost.Thread)))
+	defer end()
+
 	var imageKeyStr *string
 	if newPost.ImageKey != nil {
 		strval := newPost.ImageKey.String()
 		imageKeyStr = &strval
 	}
-	row := m.db.QueryRow(
+	row := m.db.QueryRowContext(ctx,
 		`INSERT INTO post (author, thread, creationdatetime, text, image) VALUES (
 			$1, $2, $3, $4, $5
 			) RETURNING key;`,
@@ -321,19 +526,36 @@ func (m *PostDAC) PutPost(newPost model.Post) (model.PostKey, error) {
 	return index, nil
 }
 
+// DeletePost removes a post
+func (m *PostDAC) DeletePost(ctx context.Context, postKey model.PostKey) error {
+	ctx, end := startQuery(ctx, m.dialect, "PostDAC.DeletePost", tracing.PostKey.Int(int(postKey)))
+	defer end()
+
+	res, err := m.db.ExecContext(ctx,
+		`DELETE FROM post
+			WHERE key = $1`,
+		postKey,
+	)
+	return affected(res, err, "post", postKey)
+}
+
 // ImageDAC is a image table DAC
 type ImageDAC struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect dialect
 }
 
 // NewImageDAC creates ImageDAC instance
 func NewImageDAC(db *sql.DB) *ImageDAC {
-	return &ImageDAC{db}
+	return &ImageDAC{db, dialectPostgres}
 }
 
 // IsImageExist checks image existance by key
-func (m *ImageDAC) IsImageExist(imageKey model.ImageKey) bool {
-	row := m.db.QueryRow(
+func (m *ImageDAC) IsImageExist(ctx context.Context, imageKey model.ImageKey) bool {
+	ctx, end := startQuery(ctx, m.dialect, "ImageDAC.IsImageExist")
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
 		`SELECT EXISTS( SELECT 1
 			FROM image
 			WHERE key = $1
@@ -346,18 +568,47 @@ func (m *ImageDAC) IsImageExist(imageKey model.ImageKey) bool {
 		&imageExists,
 	)
 	if err != nil {
-		log.Println("error during sql check: ", err)
+		slog.ErrorContext(ctx, "image check failed", "image", uuid.UUID(imageKey).String(), "err", err)
 		return false
 	}
 	return *imageExists
 }
 
+// GetImageList returns all images
+func (m *ImageDAC) GetImageList(ctx context.Context) ([]*model.Image, error) {
+	ctx, end := startQuery(ctx, m.dialect, "ImageDAC.GetImageList")
+	defer end()
+
+	rows, err := m.db.QueryContext(ctx, `SELECT key, filepath FROM image`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	imageList := make([]*model.Image, 0)
+	for rows.Next() {
+		var imageKey uuid.UUID
+		imageItem := &model.Image{}
+		err = rows.Scan(&imageKey, &imageItem.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		imageItem.Key = model.ImageKey(imageKey)
+		imageList = append(imageList, imageItem)
+	}
+	return imageList, rows.Err()
+}
+
 // PutImage creates a new image
-func (m *ImageDAC) PutImage(newImage *model.Image) error {
-	_, err := m.db.Exec(
+func (m *ImageDAC) PutImage(ctx context.Context, newImage *model.Image) error {
+	ctx, end := startQuery(ctx, m.dialect, "ImageDAC.PutImage")
+	defer end()
+
+	_, err := m.db.ExecContext(ctx,
 		`INSERT INTO image (key, filepath) VALUES (
 			$1, $2
-			)`,
+			)
+			ON CONFLICT (key) DO NOTHING`,
 		uuid.UUID(newImage.Key).String(),
 		newImage.FilePath,
 	)
@@ -366,17 +617,21 @@ func (m *ImageDAC) PutImage(newImage *model.Image) error {
 
 // AuthorDAC is a author table DAC
 type AuthorDAC struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect dialect
 }
 
 // NewAuthorDAC creates AuthorDAC instance
 func NewAuthorDAC(db *sql.DB) *AuthorDAC {
-	return &AuthorDAC{db}
+	return &AuthorDAC{db, dialectPostgres}
 }
 
 // GetAuthor returns author info
-func (m *AuthorDAC) GetAuthor(authorKey model.AuthorKey) (*model.Author, error) {
-	row := m.db.QueryRow(
+func (m *AuthorDAC) GetAuthor(ctx context.Context, authorKey model.AuthorKey) (*model.Author, error) {
+	ctx, end := startQuery(ctx, m.dialect, "AuthorDAC.GetAuthor")
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
 		`SELECT Key
 				FROM author
 				WHERE key = $1`,
@@ -387,7 +642,7 @@ func (m *AuthorDAC) GetAuthor(authorKey model.AuthorKey) (*model.Author, error)
 		&authorItem.Key,
 	)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, "author", authorKey)
 	}
 	return authorItem, nil
 }
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// ReportDAC is a report table DAC
+type ReportDAC struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// NewReportDAC creates ReportDAC instance
+func NewReportDAC(db *sql.DB) *ReportDAC {
+	return &ReportDAC{db, dialectPostgres}
+}
+
+// GetOpenReports returns open reports, most reported first
+func (m *ReportDAC) GetOpenReports(ctx context.Context) ([]*model.Report, error) {
+	ctx, end := startQuery(ctx, m.dialect, "ReportDAC.GetOpenReports")
+	defer end()
+
+	rows, err := m.db.QueryContext(ctx,
+		`SELECT key, post, reason, count, status, creationdatetime, updatedatetime
+			FROM report
+			WHERE status = $1
+			ORDER BY count DESC, updatedatetime DESC`,
+		model.ReportOpen,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reportList := make([]*model.Report, 0)
+	for rows.Next() {
+		reportItem := &model.Report{}
+		err = rows.Scan(
+			&reportItem.Key,
+			&reportItem.Post,
+			&reportItem.Reason,
+			&reportItem.Count,
+			&reportItem.Status,
+			&reportItem.CreationDateTime,
+			&reportItem.UpdateDateTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reportList = append(reportList, reportItem)
+	}
+	return reportList, rows.Err()
+}
+
+// GetReport returns report data
+func (m *ReportDAC) GetReport(ctx context.Context, reportKey model.ReportKey) (*model.Report, error) {
+	ctx, end := startQuery(ctx, m.dialect, "ReportDAC.GetReport")
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
+		`SELECT key, post, reason, count, status, creationdatetime, updatedatetime
+			FROM report
+			WHERE key = $1`,
+		reportKey,
+	)
+	reportItem := &model.Report{}
+	err := row.Scan(
+		&reportItem.Key,
+		&reportItem.Post,
+		&reportItem.Reason,
+		&reportItem.Count,
+		&reportItem.Status,
+		&reportItem.CreationDateTime,
+		&reportItem.UpdateDateTime,
+	)
+	if err != nil {
+		return nil, notFound(err, "report", reportKey)
+	}
+	return reportItem, nil
+}
+
+// PutReport creates a new report or increments count of the open report on the same post
+func (m *ReportDAC) PutReport(ctx context.Context, newReport model.Report) (model.ReportKey, error) {
+	ctx, end := startQuery(ctx, m.dialect, "ReportDAC.PutReport")
+	defer end()
+
+	row := m.db.QueryRowContext(ctx,
+		`INSERT INTO report (post, reason, count, status, creationdatetime, updatedatetime) VALUES (
+			$1, $2, $3, $4, $5, $6
+			)
+			ON CONFLICT (post) WHERE status = 'open' DO UPDATE
+				SET count = report.count + EXCLUDED.count,
+					updatedatetime = EXCLUDED.updatedatetime
+			RETURNING key;`,
+		newReport.Post,
+		newReport.Reason,
+		newReport.Count,
+		newReport.Status,
+		newReport.CreationDateTime,
+		newReport.UpdateDateTime,
+	)
+
+	var index model.ReportKey
+
+	err := row.Scan(&index)
+	if err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// SetReportStatus updates report status
+func (m *ReportDAC) SetReportStatus(ctx context.Context, reportKey model.ReportKey, status model.ReportStatus) error {
+	ctx, end := startQuery(ctx, m.dialect, "ReportDAC.SetReportStatus")
+	defer end()
+
+	res, err := m.db.ExecContext(ctx,
+		`UPDATE report
+			SET status = $2, updatedatetime = now()
+			WHERE key = $1`,
+		reportKey,
+		status,
+	)
+	return affected(res, err, "report", reportKey)
+}
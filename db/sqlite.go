@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/ilyakaznacheev/gochan/config"
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+const (
+	// sqliteDriverName is a name of sqlite driver with postgres compatibility functions
+	sqliteDriverName = "gochan-sqlite3"
+	// sqliteBusyTimeout is a time to wait for a lock held by another process
+	sqliteBusyTimeout = "5000"
+)
+
+// placeholderRE matches postgres $N placeholders
+var placeholderRE = regexp.MustCompile(`\$(\d+)`)
+
+func init() {
+	sql.Register(sqliteDriverName, &sqliteDriver{sqlite3.SQLiteDriver{
+		ConnectHook: registerFunctions,
+	}})
+}
+
+// OpenSQLite opens sqlite database file, it is created if it doesn't exist.
+// SQLite has a single writer, so the pool has a single connection,
+// that also keeps ":memory:" database alive while the pool is open
+func OpenSQLite(conf config.ConfigDatabase) (*sql.DB, error) {
+	query := url.Values{}
+	query.Set("_foreign_keys", "on")
+	query.Set("_txlock", "immediate")
+	query.Set("_busy_timeout", sqliteBusyTimeout)
+
+	dbConn, err := sql.Open(sqliteDriverName, "file:"+conf.Path+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	dbConn.SetMaxOpenConns(1)
+	return dbConn, nil
+}
+
+// registerFunctions adds functions used by DAC queries, that sqlite doesn't have
+func registerFunctions(conn *sqlite3.SQLiteConn) error {
+	// now returns current time in the same format the driver writes time parameters
+	err := conn.RegisterFunc("now", func() string {
+		return time.Now().Format(sqlite3.SQLiteTimestampFormats[0])
+	}, false)
+	if err != nil {
+		return err
+	}
+	return conn.RegisterFunc("inet_contains", inetContains, true)
+}
+
+// inetContains checks if IP or CIDR network contains the IP, like postgres >>= operator
+func inetContains(network, ip interface{}) bool {
+	networkStr, ok := network.(string)
+	if !ok {
+		return false
+	}
+	ipStr, ok := ip.(string)
+	if !ok {
+		return false
+	}
+	banItem := model.Ban{IP: &networkStr}
+	return banItem.Matches(ipStr, "")
+}
+
+// sqliteDriver is a sqlite3 driver accepting postgres $N placeholders.
+// SQLite numbers $N parameters in order of appearance instead of by N,
+// so they are rewritten to ?N, that are numbered explicitly
+type sqliteDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+func (d *sqliteDriver) Open(dsn string) (driver.Conn, error) {
+	conn, err := d.SQLiteDriver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteConn{conn.(*sqlite3.SQLiteConn)}, nil
+}
+
+type sqliteConn struct {
+	*sqlite3.SQLiteConn
+}
+
+func (c *sqliteConn) Prepare(query string) (driver.Stmt, error) {
+	return c.SQLiteConn.Prepare(sqliteQuery(query))
+}
+
+func (c *sqliteConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.SQLiteConn.PrepareContext(ctx, sqliteQuery(query))
+}
+
+func (c *sqliteConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.SQLiteConn.QueryContext(ctx, sqliteQuery(query), args)
+}
+
+func (c *sqliteConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.SQLiteConn.ExecContext(ctx, sqliteQuery(query), args)
+}
+
+// sqliteQuery rewrites $N placeholders to ?N
+func sqliteQuery(query string) string {
+	return placeholderRE.ReplaceAllString(query, "?$1")
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/ilyakaznacheev/gochan/config"
+	"github.com/ilyakaznacheev/gochan/db/memory"
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// dialect is a SQL flavor of the database
+type dialect string
+
+const (
+	dialectPostgres dialect = "postgres"
+	dialectSQLite   dialect = "sqlite"
+)
+
+// ErrNoSchema is returned by Migrator of the memory storage, it has no schema to migrate
+var ErrNoSchema = errors.New("storage has no schema to migrate")
+
+// Storage is a set of DACs of the configured backend
+type Storage struct {
+	// Name is a backend name: postgres, sqlite or memory
+	Name string
+
+	Board     model.BoardModelDB
+	Thread    model.ThreadModelDB
+	Post      model.PostModelDB
+	Author    model.AuthorModelDB
+	Image     model.ImageModelDB
+	Ban       model.BanModelDB
+	Filter    model.FilterModelDB
+	Report    model.ReportModelDB
+	ModAction model.ModActionModelDB
+
+	db      *sql.DB // nil for memory storage
+	dialect dialect
+}
+
+// OpenStorage opens storage selected by conf.Storage.
+// Postgres is waited for like by Connect, sqlite database file is created if it doesn't exist
+func OpenStorage(ctx context.Context, conf config.ConfigData) (*Storage, error) {
+	switch conf.Storage {
+	case "postgres":
+		dbConn, err := Connect(ctx, conf.Database)
+		if err != nil {
+			return nil, err
+		}
+		return newSQLStorage(dbConn, dialectPostgres), nil
+	case "sqlite":
+		dbConn, err := OpenSQLite(conf.Database)
+		if err != nil {
+			return nil, err
+		}
+		if err = dbConn.PingContext(ctx); err != nil {
+			dbConn.Close()
+			return nil, err
+		}
+		return newSQLStorage(dbConn, dialectSQLite), nil
+	case "memory":
+		return NewMemoryStorage(), nil
+	}
+	return nil, fmt.Errorf("unknown storage %q", conf.Storage)
+}
+
+// newSQLStorage creates storage of DACs sharing the connection pool
+func newSQLStorage(dbConn *sql.DB, sqlDialect dialect) *Storage {
+	return &Storage{
+		Name:      string(sqlDialect),
+		Board:     &BoardDAC{dbConn, sqlDialect},
+		Thread:    &ThreadDAC{dbConn, sqlDialect},
+		Post:      &PostDAC{dbConn, sqlDialect},
+		Author:    &AuthorDAC{dbConn, sqlDialect},
+		Image:     &ImageDAC{dbConn, sqlDialect},
+		Ban:       &BanDAC{dbConn, sqlDialect},
+		Filter:    &FilterDAC{dbConn, sqlDialect},
+		Report:    &ReportDAC{dbConn, sqlDialect},
+		ModAction: &ModActionDAC{dbConn, sqlDialect},
+		db:        dbConn,
+		dialect:   sqlDialect,
+	}
+}
+
+// NewMemoryStorage creates empty storage keeping data in process memory
+func NewMemoryStorage() *Storage {
+	store := memory.NewStore()
+	return &Storage{
+		Name:      "memory",
+		Board:     memory.NewBoardDAC(store),
+		Thread:    memory.NewThreadDAC(store),
+		Post:      memory.NewPostDAC(store),
+		Author:    memory.NewAuthorDAC(store),
+		Image:     memory.NewImageDAC(store),
+		Ban:       memory.NewBanDAC(store),
+		Filter:    memory.NewFilterDAC(store),
+		Report:    memory.NewReportDAC(store),
+		ModAction: memory.NewModActionDAC(store),
+	}
+}
+
+// Migrator returns migrator of the database, or ErrNoSchema for memory storage
+func (s *Storage) Migrator() (*Migrator, error) {
+	if s.db == nil {
+		return nil, ErrNoSchema
+	}
+	return newMigrator(s.db, s.dialect)
+}
+
+// Ping checks database connection, memory storage is always available
+func (s *Storage) Ping(ctx context.Context) error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.PingContext(ctx)
+}
+
+// Close closes database connection pool
+func (s *Storage) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
@@ -0,0 +1,330 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/gochan/config"
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// newTestStorages returns empty memory storage and migrated in-memory sqlite storage
+func newTestStorages(t *testing.T) map[string]*Storage {
+	t.Helper()
+
+	conf := config.GetDefaultConfig()
+	conf.Storage = "sqlite"
+	conf.Database.Path = ":memory:"
+	sqliteStorage, err := OpenStorage(context.Background(), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqliteStorage.Close() })
+
+	migrator, err := sqliteStorage.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]*Storage{
+		"memory": NewMemoryStorage(),
+		"sqlite": sqliteStorage,
+	}
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	storage := newTestStorages(t)["sqlite"]
+	migrator, err := storage.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusList, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statusItem := range statusList {
+		if !statusItem.Applied {
+			t.Errorf("migration %d %s isn't applied", statusItem.Version, statusItem.Name)
+		}
+	}
+
+	// every migration is reverted and applied again
+	for range statusList {
+		_, err = migrator.Down()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	appliedList, err := migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(appliedList) != len(statusList) {
+		t.Errorf("want %d migrations applied again, got %d", len(statusList), len(appliedList))
+	}
+}
+
+func TestSQLiteQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: `SELECT 1`, want: `SELECT 1`},
+		{query: `SELECT $1, $2`, want: `SELECT ?1, ?2`},
+		{query: `SELECT $2 WHERE a = $1 OR b = $1`, want: `SELECT ?2 WHERE a = ?1 OR b = ?1`},
+		{query: `LIMIT $10`, want: `LIMIT ?10`},
+	}
+	for _, tt := range tests {
+		if got := sqliteQuery(tt.query); got != tt.want {
+			t.Errorf("%s: want %s, got %s", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestInetContains(t *testing.T) {
+	tests := []struct {
+		network interface{}
+		ip      interface{}
+		want    bool
+	}{
+		{network: "203.0.113.7", ip: "203.0.113.7", want: true},
+		{network: "203.0.113.7", ip: "203.0.113.8", want: false},
+		{network: "203.0.113.0/24", ip: "203.0.113.8", want: true},
+		{network: "203.0.113.0/24", ip: "198.51.100.1", want: false},
+		{network: "2001:db8::/32", ip: "2001:db8::1", want: true},
+		{network: "203.0.113.0/24", ip: nil, want: false},
+		{network: nil, ip: "203.0.113.8", want: false},
+	}
+	for _, tt := range tests {
+		if got := inetContains(tt.network, tt.ip); got != tt.want {
+			t.Errorf("inet_contains(%v, %v): want %v, got %v", tt.network, tt.ip, tt.want, got)
+		}
+	}
+}
+
+// TestStorages runs the same DAC calls against every storage, that has to behave the same way
+func TestStorages(t *testing.T) {
+	for name, storage := range newTestStorages(t) {
+		storage := storage
+		t.Run(name, func(t *testing.T) {
+			testStorage(t, storage)
+		})
+	}
+}
+
+func testStorage(t *testing.T, s *Storage) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	// boards
+	err := s.Board.PutBoard(ctx, model.Board{Key: "b", Name: "Random"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	boardList, err := s.Board.GetBoardList(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boardList) != 1 || boardList[0].Name != "Random" {
+		t.Errorf("unexpected board list %+v", boardList)
+	}
+	_, err = s.Board.GetBoard(ctx, "none")
+	if !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("unknown board: want ErrNotFound, got %v", err)
+	}
+
+	// threads
+	threadKey, opKey, err := s.Thread.CreateThreadWithOP(ctx,
+		model.Thread{Title: "Thread", AuthorID: "op", BoardName: "b", CreationDateTime: now},
+		model.Post{Author: "op", CreationDateTime: now, Text: "OP post"},
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = s.Thread.CreateThreadWithOP(ctx,
+		model.Thread{Title: "Thread", AuthorID: "op", BoardName: "none", CreationDateTime: now},
+		model.Post{Author: "op", CreationDateTime: now, Text: "OP post"},
+		nil,
+	)
+	if err == nil {
+		t.Error("thread is created on unknown board")
+	}
+	err = s.Thread.SetThreadFlags(ctx, threadKey, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	threadItem, err := s.Thread.GetThread(ctx, threadKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if threadItem.Title != "Thread" || threadItem.BoardName != "b" || !threadItem.Sticky || threadItem.Locked {
+		t.Errorf("unexpected thread %+v", threadItem)
+	}
+	if !threadItem.CreationDateTime.Equal(now) {
+		t.Errorf("want thread creation time %v, got %v", now, threadItem.CreationDateTime)
+	}
+	threadList, err := s.Thread.GetTheadsByBoard(ctx, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threadList) != 1 || threadList[0].Key != threadKey {
+		t.Errorf("unexpected board threads %+v", threadList)
+	}
+	threadList, err = s.Thread.GetThreadsByAuthor(ctx, "op")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threadList) != 1 {
+		t.Errorf("want 1 thread of author, got %d", len(threadList))
+	}
+
+	// posts
+	replyKey, err := s.Post.PutPost(ctx, model.Post{Author: "replier", Thread: threadKey, CreationDateTime: now, Text: "Reply"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	postList, err := s.Post.GetPostsByThread(ctx, threadKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postList) != 2 || postList[0].Key != opKey || postList[1].Key != replyKey {
+		t.Errorf("unexpected thread posts %+v", postList)
+	}
+	postList, err = s.Post.GetPostsByAuthor(ctx, "replier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postList) != 1 || postList[0].Text != "Reply" {
+		t.Errorf("unexpected author posts %+v", postList)
+	}
+	boardName, err := s.Post.GetPostBoard(ctx, replyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if boardName != "b" {
+		t.Errorf("want post board b, got %s", boardName)
+	}
+
+	// bans
+	network := "203.0.113.0/24"
+	banKey, err := s.Ban.PutBan(ctx, model.Ban{IP: &network, Reason: "network", CreationDateTime: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	author := model.AuthorKey("troll")
+	expiration := now.Add(-time.Hour)
+	_, err = s.Ban.PutBan(ctx, model.Ban{Author: &author, Reason: "expired", CreationDateTime: now.Add(-2 * time.Hour), ExpirationDateTime: &expiration})
+	if err != nil {
+		t.Fatal(err)
+	}
+	findTests := []struct {
+		name   string
+		ip     string
+		author model.AuthorKey
+		want   model.BanKey
+	}{
+		{name: "ip in network", ip: "203.0.113.7", author: "poster", want: banKey},
+		{name: "ip out of network", ip: "198.51.100.1", author: "poster"},
+		{name: "unknown ip", ip: "", author: "poster"},
+		{name: "non-ip peer", ip: "@", author: "poster"},
+		{name: "expired author ban", ip: "198.51.100.1", author: author},
+	}
+	for _, tt := range findTests {
+		banItem, err := s.Ban.FindBan(ctx, tt.ip, tt.author, "b")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got model.BanKey
+		if banItem != nil {
+			got = banItem.Key
+		}
+		if got != tt.want {
+			t.Errorf("%s: want ban %d, got %d", tt.name, tt.want, got)
+		}
+	}
+	_, err = s.Ban.PutAppeal(ctx, model.BanAppeal{Ban: banKey, Text: "sorry", CreationDateTime: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appealList, err := s.Ban.GetAppealsByBan(ctx, banKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(appealList) != 1 || appealList[0].Text != "sorry" {
+		t.Errorf("unexpected appeals %+v", appealList)
+	}
+	err = s.Ban.LiftBan(ctx, banKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	banItem, err := s.Ban.GetBan(ctx, banKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if banItem.ExpirationDateTime == nil || banItem.ExpirationDateTime.After(time.Now()) {
+		t.Errorf("lifted ban expires at %v", banItem.ExpirationDateTime)
+	}
+
+	// reports are counted per post, until they are resolved
+	reportKey, err := s.Report.PutReport(ctx, model.Report{Post: replyKey, Reason: "spam", Count: 1, Status: model.ReportOpen, CreationDateTime: now, UpdateDateTime: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Report.PutReport(ctx, model.Report{Post: replyKey, Reason: "spam", Count: 1, Status: model.ReportOpen, CreationDateTime: now, UpdateDateTime: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reportList, err := s.Report.GetOpenReports(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reportList) != 1 || reportList[0].Key != reportKey || reportList[0].Count != 2 {
+		t.Errorf("unexpected open reports %+v", reportList)
+	}
+	err = s.Report.SetReportStatus(ctx, reportKey, model.ReportResolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reportList, err = s.Report.GetOpenReports(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reportList) != 0 {
+		t.Errorf("want no open reports, got %d", len(reportList))
+	}
+
+	// post deletion
+	err = s.Post.DeletePost(ctx, replyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Post.GetPost(ctx, replyKey)
+	if !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("deleted post: want ErrNotFound, got %v", err)
+	}
+	err = s.Post.DeletePost(ctx, replyKey)
+	if !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("deleted post deletion: want ErrNotFound, got %v", err)
+	}
+
+	// moderation log
+	_, err = s.ModAction.PutModAction(ctx, model.ModAction{Moderator: "admin", Action: model.ModPostDelete, Board: &boardName, Post: &replyKey, CreationDateTime: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actionList, err := s.ModAction.GetModActions(ctx, model.ModActionFilter{Board: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actionList) != 1 || actionList[0].Action != model.ModPostDelete || *actionList[0].Post != replyKey {
+		t.Errorf("unexpected moderation log %+v", actionList)
+	}
+}
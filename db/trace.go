@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/ilyakaznacheev/gochan/metrics"
+	"github.com/ilyakaznacheev/gochan/tracing"
+)
+
+// dbSystems are OpenTelemetry db.system names of SQL dialects
+var dbSystems = map[dialect]string{
+	dialectPostgres: "postgresql",
+	dialectSQLite:   "sqlite",
+}
+
+// startQuery starts span of DAC method, returned function ends it and records query latency
+func startQuery(ctx context.Context, sqlDialect dialect, method string, attrs ...attribute.KeyValue) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, method, append(attrs, attribute.String("db.system", dbSystems[sqlDialect]))...)
+	return ctx, func() {
+		metrics.ObserveQuery(method, start)
+		span.End()
+	}
+}
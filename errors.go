@@ -0,0 +1,186 @@
+package gochan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/google/uuid"
+
+	"github.com/ilyakaznacheev/gochan/logging"
+	"github.com/ilyakaznacheev/gochan/metrics"
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+// ErrorRepr is a context for error page templates
+type ErrorRepr struct {
+	Status    int
+	Title     string
+	Message   string
+	RequestID string
+}
+
+// errorStatus maps model errors to HTTP status codes
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, model.ErrBanned), errors.Is(err, model.ErrLocked):
+		return http.StatusForbidden
+	case errors.Is(err, model.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, model.ErrRateLimited):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorCode maps model errors to GraphQL error codes
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		return "NOT_FOUND"
+	case errors.Is(err, model.ErrBanned):
+		return "BANNED"
+	case errors.Is(err, model.ErrLocked):
+		return "LOCKED"
+	case errors.Is(err, model.ErrValidation):
+		return "BAD_INPUT"
+	case errors.Is(err, model.ErrRateLimited):
+		return "RATE_LIMITED"
+	default:
+		return "INTERNAL"
+	}
+}
+
+// ResolverError is a GraphQL resolver error with code and status extensions
+type ResolverError struct {
+	err error
+}
+
+// newResolverError wraps model error for GraphQL response.
+// Internal error details are logged and hidden from the client
+func newResolverError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ResolverError{err}
+}
+
+func (e *ResolverError) Error() string {
+	if errorStatus(e.err) == http.StatusInternalServerError {
+		return "internal error"
+	}
+	return e.err.Error()
+}
+
+// Unwrap returns original model error
+func (e *ResolverError) Unwrap() error {
+	return e.err
+}
+
+// Extensions returns GraphQL error extensions
+func (e *ResolverError) Extensions() map[string]interface{} {
+	status := errorStatus(e.err)
+	if status == http.StatusInternalServerError {
+		slog.Error("resolver failed", "err", e.err)
+	}
+
+	ext := map[string]interface{}{
+		"code":   errorCode(e.err),
+		"status": status,
+	}
+	var banErr *model.BanError
+	if errors.As(e.err, &banErr) {
+		ext["ban"] = int(banErr.Ban.Key)
+	}
+	return ext
+}
+
+// withRequestID assigns unique ID to each request,
+// so the user can refer to it when reporting internal errors
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := uuid.New().String()
+		w.Header().Set("X-Request-ID", requestID)
+		ctx := logging.WithRequestID(r.Context(), requestID)
+		slog.DebugContext(ctx, "request", "method", r.Method, "path", r.URL.Path)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func requestID(ctx context.Context) string {
+	return logging.RequestID(ctx)
+}
+
+// Recover is a middleware turning handler panics into internal error pages,
+// so a broken request gets an answer with request ID instead of a dropped connection
+func (rh *ChanRequestHandler) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				// the handler aborts the response on purpose
+				panic(rec)
+			}
+
+			metrics.PanicRecovered()
+			slog.ErrorContext(r.Context(), "handler panic", "panic", rec, "stack", string(debug.Stack()))
+			if recorder.written {
+				// the response is already started, the client just gets a cut page
+				return
+			}
+			rh.renderStatus(w, r, http.StatusInternalServerError, "")
+		}()
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// renderError renders error page with status of the model error.
+// Internal errors are logged, and the page shows only the request ID
+func (rh *ChanRequestHandler) renderError(w http.ResponseWriter, r *http.Request, err error) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "request failed", "err", err)
+		rh.renderStatus(w, r, status, "")
+		return
+	}
+	rh.renderStatus(w, r, status, err.Error())
+}
+
+// renderStatus renders error_<status>.html template,
+// or generic error.html if there is no page for the status
+func (rh *ChanRequestHandler) renderStatus(w http.ResponseWriter, r *http.Request, status int, message string) {
+	ctxError := ErrorRepr{
+		Status:    status,
+		Title:     http.StatusText(status),
+		Message:   message,
+		RequestID: requestID(r.Context()),
+	}
+
+	err := rh.templates.render(w, status, fmt.Sprintf("error_%d.html", status), ctxError)
+	if errors.Is(err, fs.ErrNotExist) {
+		err = rh.templates.render(w, status, "error.html", ctxError)
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "can't render error page", "err", err)
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+// renderPage renders page template, or error page if the template fails
+func (rh *ChanRequestHandler) renderPage(w http.ResponseWriter, r *http.Request, status int, name string, data interface{}) {
+	err := rh.templates.render(w, status, name, data)
+	if err != nil {
+		rh.renderError(w, r, err)
+	}
+}
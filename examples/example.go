@@ -1,13 +1,46 @@
 package main
 
 import (
+	"log"
+	"os"
+
 	"github.com/ilyakaznacheev/gochan"
+	"github.com/ilyakaznacheev/gochan/config"
+	"github.com/ilyakaznacheev/gochan/logging"
 )
 
 func main() {
-	// create server with default config
-	s := gochan.NewServer(nil)
+	// load config from -config file, GOCHAN_* environment variables and flags
+	conf, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := logging.Setup(os.Stderr, conf.Log); err != nil {
+		log.Fatal(err)
+	}
+	conf.Print(os.Stdout)
+
+	s := gochan.NewServer(&conf)
+
+	// "migrate up|down|status" manages database schema only
+	if len(args) > 0 && args[0] == "migrate" {
+		command := "up"
+		if len(args) > 1 {
+			command = args[1]
+		}
+		if err := s.Migrate(command); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// admin credentials are required to serve
+	if err := conf.ValidateServe(); err != nil {
+		log.Fatal(err)
+	}
 
 	// and just run it
-	s.Run()
+	if err := s.Run(); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -1,29 +1,34 @@
 package gochan
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"errors"
-	"html/template"
 	"io"
-	"log"
+	"log/slog"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+
+	"github.com/ilyakaznacheev/gochan/captcha"
+	"github.com/ilyakaznacheev/gochan/logging"
+	"github.com/ilyakaznacheev/gochan/metrics"
 	"github.com/ilyakaznacheev/gochan/model"
 )
 
 const (
-	templatePath = "static/template/"
-	imgPath      = "media/img/"
-	timeFormat   = "Mon _2 Jan 2006 15:04:05"
+	imgPath    = "media/img/"
+	timeFormat = "Mon _2 Jan 2006 15:04:05"
 )
 
 // MainRepr is a context for main.html template
@@ -39,12 +44,15 @@ type BoardRepr struct {
 	Time      string
 	ImagePath string
 	HasImage  bool
+	Sticky    bool
+	Locked    bool
 }
 
 // BoardReprInfo is a part of board template context
 type BoardReprInfo struct {
-	Name string
-	Key  string
+	Name    string
+	Key     string
+	Captcha string
 }
 
 // PostRepr is a context for post.html template
@@ -68,13 +76,35 @@ type ThreadReprInfo struct {
 	Key    string
 	Title  string
 	Author string
+	Locked bool
 }
 
 // ThreadRepr is a context for thread.html template
 type ThreadRepr struct {
-	Board  ThreadReprBoard
-	Thread ThreadReprInfo
-	Posts  []PostRepr
+	Board   ThreadReprBoard
+	Thread  ThreadReprInfo
+	Posts   []PostRepr
+	Captcha string
+}
+
+// BanRepr is a context for ban.html template
+type BanRepr struct {
+	Key      string
+	IP       string
+	Author   string
+	Board    string
+	Reason   string
+	Time     string
+	Expires  string
+	Active   bool
+	Appealed bool
+	Appeals  []BanAppealRepr
+}
+
+// BanAppealRepr is a part of ban template context
+type BanAppealRepr struct {
+	Text string
+	Time string
 }
 
 // RequestHandler is a common request handler interface
@@ -85,15 +115,42 @@ type RequestHandler interface {
 	AddMessage(http.ResponseWriter, *http.Request)
 	AddThread(http.ResponseWriter, *http.Request)
 	AuthorPage(http.ResponseWriter, *http.Request)
+	NotFound(http.ResponseWriter, *http.Request)
+	Recover(http.Handler) http.Handler
+	Healthz(http.ResponseWriter, *http.Request)
+	Readyz(http.ResponseWriter, *http.Request)
+	BanAppeal(http.ResponseWriter, *http.Request)
+	CaptchaImage(http.ResponseWriter, *http.Request)
+	ReportPost(http.ResponseWriter, *http.Request)
 	AdminPage(http.ResponseWriter, *http.Request)
+	AdminBoardPage(http.ResponseWriter, *http.Request)
+	AdminToggleCaptcha(http.ResponseWriter, *http.Request)
+	AdminThreadPage(http.ResponseWriter, *http.Request)
+	AdminToggleSticky(http.ResponseWriter, *http.Request)
+	AdminToggleLock(http.ResponseWriter, *http.Request)
+	AdminFilterPage(http.ResponseWriter, *http.Request)
+	AdminAddFilter(http.ResponseWriter, *http.Request)
+	AdminDeleteFilter(http.ResponseWriter, *http.Request)
+	AdminReportPage(http.ResponseWriter, *http.Request)
+	AdminDismissReport(http.ResponseWriter, *http.Request)
+	AdminDeleteReported(http.ResponseWriter, *http.Request)
+	AdminBanReported(http.ResponseWriter, *http.Request)
+	AdminModLogPage(http.ResponseWriter, *http.Request)
+	AdminModLogExport(http.ResponseWriter, *http.Request)
+	AdminBanPage(http.ResponseWriter, *http.Request)
+	AdminAddBan(http.ResponseWriter, *http.Request)
+	AdminLiftBan(http.ResponseWriter, *http.Request)
 }
 
 // ChanRequestHandler handles http requests
 type ChanRequestHandler struct {
-	model *modelContext
+	model     *modelContext
+	templates *templateRegistry
 }
 
-func (rh *ChanRequestHandler) uploadImage(r *http.Request) (*uuid.UUID, error) {
+// uploadImage saves posted picture into media folder.
+// Returned image isn't saved into db yet, that is up to the caller
+func (rh *ChanRequestHandler) uploadImage(r *http.Request) (*model.Image, error) {
 
 	file, handler, err := r.FormFile("picture")
 	if err != nil {
@@ -123,6 +180,8 @@ func (rh *ChanRequestHandler) uploadImage(r *http.Request) (*uuid.UUID, error) {
 	hasher := md5.New()
 	_, err = io.Copy(newFile, io.TeeReader(file, hasher))
 	if err != nil {
+		newFile.Close()
+		os.Remove(tmpFile)
 		return nil, errors.New("cant save file: " + err.Error())
 	}
 	newFile.Sync()
@@ -137,9 +196,10 @@ func (rh *ChanRequestHandler) uploadImage(r *http.Request) (*uuid.UUID, error) {
 	}
 	md5Sum := fileUUID.String()
 
-	if rh.model.imageModel.IsImageExist(model.ImageKey(fileUUID)) {
+	if rh.model.imageModel.IsImageExist(r.Context(), model.ImageKey(fileUUID)) {
 		os.Remove(tmpFile)
-		return &fileUUID, nil
+		metrics.ImageUploaded()
+		return &model.Image{Key: model.ImageKey(fileUUID)}, nil
 	}
 
 	realFile := filepath.Join(imgPath, md5Sum+fileExt)
@@ -148,24 +208,18 @@ func (rh *ChanRequestHandler) uploadImage(r *http.Request) (*uuid.UUID, error) {
 		return nil, errors.New("cant raname file: " + err.Error())
 	}
 
-	log.Println("new file upload:", realFile)
+	slog.InfoContext(r.Context(), "new file upload", "file", realFile)
+	metrics.ImageUploaded()
 
-	err = rh.model.imageModel.PutImage(&model.Image{
+	return &model.Image{
 		Key:      model.ImageKey(fileUUID),
 		FilePath: realFile,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	return &fileUUID, nil
+	}, nil
 }
 
 // MainPage returns index page
 func (rh *ChanRequestHandler) MainPage(w http.ResponseWriter, r *http.Request) {
-	tmpl := template.Must(template.ParseFiles(templatePath + "home.html"))
-
-	modelData := rh.model.boardModel.GetList()
+	modelData := rh.model.boardModel.GetList(r.Context())
 
 	ctxBoards := make([]MainRepr, 0, len(modelData))
 
@@ -176,23 +230,24 @@ func (rh *ChanRequestHandler) MainPage(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	tmpl.Execute(w, struct{ Boards []MainRepr }{ctxBoards})
+	rh.renderPage(w, r, http.StatusOK, "home.html", struct{ Boards []MainRepr }{ctxBoards})
 
 }
 
 // BoardPage returns board page
 func (rh *ChanRequestHandler) BoardPage(w http.ResponseWriter, r *http.Request) {
-	tmpl := template.Must(template.ParseFiles(templatePath + "board.html"))
 	requestParams := mux.Vars(r)
 
-	boardData, err := rh.model.boardModel.GetItem(model.BoardKey(requestParams["board"]))
+	boardData, err := rh.model.boardModel.GetItem(r.Context(), model.BoardKey(requestParams["board"]))
 	if err != nil {
-		log.Println(err)
+		rh.renderError(w, r, err)
+		return
 	}
 
-	modelData, err := rh.model.threadModel.GetTheadsByBoard(model.BoardKey(requestParams["board"]))
+	modelData, err := rh.model.threadModel.GetTheadsByBoard(r.Context(), boardData.Key)
 	if err != nil {
-		log.Println(err)
+		rh.renderError(w, r, err)
+		return
 	}
 
 	ctxThreads := make([]BoardRepr, 0, len(modelData))
@@ -204,40 +259,42 @@ func (rh *ChanRequestHandler) BoardPage(w http.ResponseWriter, r *http.Request)
 			Time:      threadItem.CreationDateTime.Format(timeFormat),
 			ImagePath: threadItem.GetImagePath(),
 			HasImage:  threadItem.ImagePath != nil,
+			Sticky:    threadItem.Sticky,
+			Locked:    threadItem.Locked,
 		})
 	}
 
-	tmpl.Execute(w, struct {
+	rh.renderPage(w, r, http.StatusOK, "board.html", struct {
 		Board   BoardReprInfo
 		Threads []BoardRepr
-	}{BoardReprInfo{boardData.Name, string(boardData.Key)}, ctxThreads})
+	}{BoardReprInfo{
+		boardData.Name,
+		string(boardData.Key),
+		rh.newCaptcha(r.Context(), boardData, readAuthorID(r)),
+	}, ctxThreads})
 }
 
 // ThreadPage returns thread page
 func (rh *ChanRequestHandler) ThreadPage(w http.ResponseWriter, r *http.Request) {
-	tmpl := template.Must(template.ParseFiles(templatePath + "thread.html"))
 	requestParams := mux.Vars(r)
 
 	threadIDReq, _ := strconv.Atoi(requestParams["id"])
 
-	threadData, err := rh.model.threadModel.GetThread(model.ThreadKey(threadIDReq))
+	threadData, err := rh.model.threadModel.GetThread(r.Context(), model.ThreadKey(threadIDReq))
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		rh.renderError(w, r, err)
 		return
 	}
 
-	boardData, err := rh.model.boardModel.GetItem(threadData.BoardName)
+	boardData, err := rh.model.boardModel.GetItem(r.Context(), threadData.BoardName)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		rh.renderError(w, r, err)
 		return
 	}
 
-	postData, err := rh.model.postModel.GetPostsByThread(model.ThreadKey(threadIDReq))
+	postData, err := rh.model.postModel.GetPostsByThread(r.Context(), model.ThreadKey(threadIDReq))
 	if err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		rh.renderError(w, r, err)
 		return
 	}
 
@@ -247,8 +304,10 @@ func (rh *ChanRequestHandler) ThreadPage(w http.ResponseWriter, r *http.Request)
 		Key:    strconv.Itoa(int(threadData.Key)),
 		Title:  threadData.Title,
 		Author: string(threadData.AuthorID),
+		Locked: threadData.Locked,
 	}
 
+	ctxThread.Captcha = rh.newCaptcha(r.Context(), boardData, readAuthorID(r))
 	ctxThread.Posts = make([]PostRepr, 0, len(postData))
 
 	for _, threadItem := range postData {
@@ -264,7 +323,7 @@ func (rh *ChanRequestHandler) ThreadPage(w http.ResponseWriter, r *http.Request)
 		})
 	}
 
-	tmpl.Execute(w, ctxThread)
+	rh.renderPage(w, r, http.StatusOK, "thread.html", ctxThread)
 }
 
 // AddMessage adds new message to thread
@@ -272,10 +331,15 @@ func (rh *ChanRequestHandler) AddMessage(w http.ResponseWriter, r *http.Request)
 	requestParams := mux.Vars(r)
 	ThreadID, _ := strconv.Atoi(requestParams["id"])
 
-	// read file
-	fileUUID, err := rh.uploadImage(r)
+	threadData, err := rh.model.threadModel.GetThread(r.Context(), model.ThreadKey(ThreadID))
 	if err != nil {
-		log.Println("error while file upload", err)
+		rh.renderError(w, r, err)
+		return
+	}
+
+	if threadData.Locked {
+		rh.renderError(w, r, model.ErrLocked)
+		return
 	}
 
 	// check cookie
@@ -297,9 +361,42 @@ func (rh *ChanRequestHandler) AddMessage(w http.ResponseWriter, r *http.Request)
 		AuthorID = authorCookie.Value
 	}
 
+	if rh.isBanned(w, r, model.AuthorKey(AuthorID), threadData.BoardName) {
+		return
+	}
+
+	boardData, err := rh.model.boardModel.GetItem(r.Context(), threadData.BoardName)
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	if !rh.checkCaptcha(r, boardData, model.AuthorKey(AuthorID)) {
+		rh.renderError(w, r, model.ErrCaptchaFailed)
+		return
+	}
+
 	inputText := r.FormValue("message")
 
-	log.Println("New message by", AuthorID, inputText)
+	filterResult, err := rh.model.filterModel.Apply(r.Context(), threadData.BoardName, model.AuthorKey(AuthorID), &inputText)
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	// read file
+	var fileUUID *uuid.UUID
+	imageData, err := rh.uploadImage(r)
+	if err != nil {
+		slog.WarnContext(r.Context(), "file upload failed", "err", err)
+	} else if err = rh.model.imageModel.PutImage(r.Context(), imageData); err != nil {
+		slog.ErrorContext(r.Context(), "image save failed", "err", err)
+	} else {
+		imageKey := uuid.UUID(imageData.Key)
+		fileUUID = &imageKey
+	}
+
+	slog.InfoContext(r.Context(), "new post", "thread", threadData.Key, logging.Author(AuthorID), logging.Text("text", inputText))
 
 	// save post data
 	newPost := model.Post{
@@ -309,10 +406,13 @@ func (rh *ChanRequestHandler) AddMessage(w http.ResponseWriter, r *http.Request)
 		Text:             inputText,
 		ImageKey:         fileUUID,
 	}
-	_, err = rh.model.postModel.PutPost(newPost)
+	PostID, err := rh.model.postModel.PutPost(r.Context(), newPost)
 	if err != nil {
-		log.Println(err)
+		rh.renderError(w, r, err)
+		return
 	}
+	rh.model.logFilterMatches(r.Context(), filterResult, threadData.Key, PostID)
+
 	http.Redirect(w, r, "/thread/"+strconv.Itoa(ThreadID), http.StatusFound)
 }
 
@@ -321,12 +421,6 @@ func (rh *ChanRequestHandler) AddThread(w http.ResponseWriter, r *http.Request)
 	requestParams := mux.Vars(r)
 	BoardName := model.BoardKey(requestParams["board"])
 
-	// read file
-	fileUUID, err := rh.uploadImage(r)
-	if err != nil {
-		log.Println("error whila file upload", err)
-	}
-
 	authorCookie, err := r.Cookie("author_id")
 
 	var AuthorID string
@@ -344,50 +438,93 @@ func (rh *ChanRequestHandler) AddThread(w http.ResponseWriter, r *http.Request)
 		AuthorID = authorCookie.Value
 	}
 
+	if rh.isBanned(w, r, model.AuthorKey(AuthorID), BoardName) {
+		return
+	}
+
+	boardData, err := rh.model.boardModel.GetItem(r.Context(), BoardName)
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	if !rh.checkCaptcha(r, boardData, model.AuthorKey(AuthorID)) {
+		rh.renderError(w, r, model.ErrCaptchaFailed)
+		return
+	}
+
 	inputTitle := r.FormValue("title")
 	inputText := r.FormValue("message")
 
-	log.Println("New thread by", AuthorID, inputTitle)
+	filterResult, err := rh.model.filterModel.Apply(r.Context(), BoardName, model.AuthorKey(AuthorID), &inputTitle, &inputText)
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	// read file
+	imageData, err := rh.uploadImage(r)
+	if err != nil {
+		slog.WarnContext(r.Context(), "file upload failed", "err", err)
+	}
+
+	slog.InfoContext(r.Context(), "new thread", "board", BoardName, logging.Author(AuthorID), logging.Text("title", inputTitle))
 
+	creationTime := time.Now()
 	newThread := model.Thread{
 		Title:            inputTitle,
 		AuthorID:         model.AuthorKey(AuthorID),
 		BoardName:        BoardName,
-		CreationDateTime: time.Now(),
-		ImageKey:         fileUUID,
+		CreationDateTime: creationTime,
+	}
+	newPost := model.Post{
+		Author:           model.AuthorKey(AuthorID),
+		CreationDateTime: creationTime,
+		Text:             inputText,
 	}
 
-	ThreadID, err := rh.model.threadModel.PutThread(newThread)
+	ThreadID, PostID, err := rh.model.threadModel.CreateThreadWithOP(r.Context(), newThread, newPost, imageData)
 	if err != nil {
-		log.Println(err)
+		rh.renderError(w, r, err)
+		return
 	}
+	rh.model.logFilterMatches(r.Context(), filterResult, ThreadID, PostID)
 
-	log.Println("New message by", AuthorID, inputText)
+	http.Redirect(w, r, "/"+string(BoardName), http.StatusFound)
+}
 
-	newPost := model.Post{
-		Author:           model.AuthorKey(AuthorID),
-		Thread:           model.ThreadKey(ThreadID),
-		CreationDateTime: time.Now(),
-		Text:             inputText,
-		ImageKey:         fileUUID,
+// ReportPost sends post to moderation queue
+func (rh *ChanRequestHandler) ReportPost(w http.ResponseWriter, r *http.Request) {
+	requestParams := mux.Vars(r)
+	PostID, _ := strconv.Atoi(requestParams["id"])
+
+	postData, err := rh.model.postModel.GetPost(r.Context(), model.PostKey(PostID))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
 	}
-	_, err = rh.model.postModel.PutPost(newPost)
+
+	reportID, err := rh.model.reportModel.PutReport(r.Context(), postData.Key, r.FormValue("reason"))
 	if err != nil {
-		log.Println(err)
+		rh.renderError(w, r, err)
+		return
 	}
-	http.Redirect(w, r, "/"+string(BoardName), http.StatusFound)
+
+	slog.InfoContext(r.Context(), "post reported", "post", postData.Key, "report", reportID)
+
+	http.Redirect(w, r, "/thread/"+postData.Thread.String(), http.StatusFound)
 }
 
 // AuthorPage returns all messages by Author selected
 func (rh *ChanRequestHandler) AuthorPage(w http.ResponseWriter, r *http.Request) {
-	tmpl := template.Must(template.ParseFiles(templatePath + "author.html"))
 	requestParams := mux.Vars(r)
 
 	AuthorID := model.AuthorKey(requestParams["author"])
 
-	authorData, err := rh.model.postModel.GetPostsByAuthor(AuthorID)
+	authorData, err := rh.model.postModel.GetPostsByAuthor(r.Context(), AuthorID)
 	if err != nil {
-		log.Println(err)
+		rh.renderError(w, r, err)
+		return
 	}
 
 	var ctxThread ThreadRepr
@@ -407,16 +544,196 @@ func (rh *ChanRequestHandler) AuthorPage(w http.ResponseWriter, r *http.Request)
 		})
 	}
 
-	tmpl.Execute(w, ctxThread)
+	rh.renderPage(w, r, http.StatusOK, "author.html", ctxThread)
 }
 
-// AdminPage loads admin cockpit
-func (rh *ChanRequestHandler) AdminPage(w http.ResponseWriter, r *http.Request) {
+// isBanned checks poster bans on the board and renders ban page if any
+func (rh *ChanRequestHandler) isBanned(w http.ResponseWriter, r *http.Request, authorID model.AuthorKey, boardName model.BoardKey) bool {
+	banData, err := rh.model.banModel.CheckBan(r.Context(), getClientIP(r), authorID, boardName)
+	if err != nil {
+		rh.renderError(w, r, err)
+		return true
+	}
+	if banData == nil {
+		return false
+	}
 
+	slog.InfoContext(r.Context(), "banned poster rejected", "ban", banData.Key)
+
+	rh.renderPage(w, r, http.StatusForbidden, "ban.html", newBanRepr(banData, nil))
+	return true
 }
 
-func newRequestHandler(model *modelContext) RequestHandler {
-	return &ChanRequestHandler{model}
+// BanAppeal adds an appeal to the ban
+func (rh *ChanRequestHandler) BanAppeal(w http.ResponseWriter, r *http.Request) {
+	requestParams := mux.Vars(r)
+
+	banIDReq, _ := strconv.Atoi(requestParams["id"])
+
+	banData, err := rh.model.banModel.GetBan(r.Context(), model.BanKey(banIDReq))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	// only banned poster can appeal
+	if !banData.Matches(getClientIP(r), readAuthorID(r)) {
+		rh.renderStatus(w, r, http.StatusForbidden, "ban doesn't apply to you")
+		return
+	}
+
+	_, err = rh.model.banModel.PutAppeal(r.Context(), model.BanAppeal{
+		Ban:              banData.Key,
+		Text:             r.FormValue("text"),
+		CreationDateTime: time.Now(),
+	})
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "new ban appeal", "ban", banData.Key)
+
+	ctxBan := newBanRepr(banData, nil)
+	ctxBan.Appealed = true
+	rh.renderPage(w, r, http.StatusOK, "ban.html", ctxBan)
+}
+
+// NotFound renders 404 page for unknown paths
+func (rh *ChanRequestHandler) NotFound(w http.ResponseWriter, r *http.Request) {
+	rh.renderStatus(w, r, http.StatusNotFound, "")
+}
+
+func newRequestHandler(model *modelContext, templates *templateRegistry) RequestHandler {
+	return &ChanRequestHandler{model, templates}
+}
+
+// newCaptcha creates captcha challenge if the author has to solve it on the board
+func (rh *ChanRequestHandler) newCaptcha(ctx context.Context, boardData *model.Board, authorID model.AuthorKey) string {
+	if !rh.model.captchaModel.IsRequired(ctx, boardData, authorID) {
+		return ""
+	}
+	captchaID, err := rh.model.captchaModel.NewChallenge(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "can't create captcha", "err", err)
+	}
+	return string(captchaID)
+}
+
+// checkCaptcha verifies captcha answer if the board requires it
+func (rh *ChanRequestHandler) checkCaptcha(r *http.Request, boardData *model.Board, authorID model.AuthorKey) bool {
+	if !rh.model.captchaModel.IsRequired(r.Context(), boardData, authorID) {
+		return true
+	}
+	return rh.model.captchaModel.Verify(
+		r.Context(),
+		model.CaptchaKey(r.FormValue("captcha_id")),
+		r.FormValue("captcha_answer"),
+		authorID,
+	)
+}
+
+// CaptchaImage returns captcha challenge image
+func (rh *ChanRequestHandler) CaptchaImage(w http.ResponseWriter, r *http.Request) {
+	requestParams := mux.Vars(r)
+
+	digits, err := rh.model.captchaModel.GetDigits(r.Context(), model.CaptchaKey(requestParams["id"]))
+	if err != nil {
+		rh.renderError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	err = captcha.WritePNG(w, digits)
+	if err != nil {
+		slog.WarnContext(r.Context(), "can't write captcha image", "err", err)
+	}
+}
+
+func newBanRepr(banData *model.Ban, appealData []*model.BanAppeal) BanRepr {
+	ctxBan := BanRepr{
+		Key:     strconv.Itoa(int(banData.Key)),
+		Board:   banData.GetBoard(),
+		Reason:  banData.Reason,
+		Time:    banData.CreationDateTime.Format(timeFormat),
+		Active:  banData.IsActive(time.Now()),
+		Appeals: make([]BanAppealRepr, 0, len(appealData)),
+	}
+	if banData.IP != nil {
+		ctxBan.IP = *banData.IP
+	}
+	if banData.Author != nil {
+		ctxBan.Author = string(*banData.Author)
+	}
+	if banData.ExpirationDateTime != nil {
+		ctxBan.Expires = banData.ExpirationDateTime.Format(timeFormat)
+	}
+	for _, appealItem := range appealData {
+		ctxBan.Appeals = append(ctxBan.Appeals, BanAppealRepr{
+			Text: appealItem.Text,
+			Time: appealItem.CreationDateTime.Format(timeFormat),
+		})
+	}
+	return ctxBan
+}
+
+// readAuthorID returns author ID from cookie without issuing a new one
+func readAuthorID(r *http.Request) model.AuthorKey {
+	authorCookie, err := r.Cookie("author_id")
+	if err != nil {
+		return ""
+	}
+	return model.AuthorKey(authorCookie.Value)
+}
+
+// withClientIP puts client IP into request context. It is taken from the trusted proxy header if it is set,
+// or from the peer address. Addresses that aren't IPs, like unix socket peers, are dropped
+func withClientIP(header string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var clientIP string
+		if header != "" {
+			clientIP = headerIP(r.Header.Values(header))
+		}
+		if clientIP == "" {
+			clientIP = peerIP(r.RemoteAddr)
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxClientIP, clientIP)))
+	})
+}
+
+// headerIP returns the last address of proxy header, it is the one added by the trusted proxy.
+// Earlier addresses are sent by the client and can be forged
+func headerIP(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	addressList := strings.Split(values[len(values)-1], ",")
+	address := strings.TrimSpace(addressList[len(addressList)-1])
+	if net.ParseIP(address) == nil {
+		return ""
+	}
+	return address
+}
+
+// peerIP returns IP of the peer address, or empty string if it isn't an IP
+func peerIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	if net.ParseIP(host) == nil {
+		return ""
+	}
+	return host
+}
+
+// getClientIP returns request client IP, or empty string if it is unknown
+func getClientIP(r *http.Request) string {
+	if clientIP, ok := r.Context().Value(ctxClientIP).(string); ok {
+		return clientIP
+	}
+	return peerIP(r.RemoteAddr)
 }
 
 var letterRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
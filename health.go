@@ -0,0 +1,93 @@
+package gochan
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/ilyakaznacheev/gochan/metrics"
+)
+
+// readyTimeout limits readiness check of a single dependency
+const readyTimeout = 2 * time.Second
+
+// Healthz reports that the process is up
+func (rh *ChanRequestHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("ok\n"))
+}
+
+// Readyz reports whether the database and redis are available
+func (rh *ChanRequestHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	storage := rh.model.storage
+	checks := map[string]string{
+		storage.Name: "ok",
+		"redis":      "ok",
+	}
+	status := http.StatusOK
+	if err := storage.Ping(ctx); err != nil {
+		checks[storage.Name] = err.Error()
+		status = http.StatusServiceUnavailable
+	}
+	if err := rh.model.repoConnection.Ping(ctx); err != nil {
+		checks["redis"] = err.Error()
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(checks)
+}
+
+// statusRecorder remembers response status for metrics, and whether the response is started
+type statusRecorder struct {
+	http.ResponseWriter
+	status  int
+	written bool
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if !sr.written {
+		sr.status = status
+		sr.written = true
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(data []byte) (int, error) {
+	sr.written = true
+	return sr.ResponseWriter.Write(data)
+}
+
+// Unwrap returns original writer, so http.ResponseController can reach it
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// routeTemplate returns path template of the matched route, so metrics and spans
+// are grouped by route instead of every board or thread
+func routeTemplate(r *http.Request) string {
+	if currentRoute := mux.CurrentRoute(r); currentRoute != nil {
+		if template, err := currentRoute.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unknown"
+}
+
+// withMetrics records latency of matched routes by their path template
+func withMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(recorder, r)
+		metrics.ObserveRequest(route, r.Method, recorder.status, time.Since(start))
+	})
+}
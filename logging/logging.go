@@ -0,0 +1,98 @@
+// Package logging configures structured logging and carries request ID in context,
+// so every log line of a request can be found by the ID shown to the user
+package logging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ilyakaznacheev/gochan/config"
+)
+
+type contextKey string
+
+const ctxRequestID contextKey = "request-id"
+
+// WithRequestID returns context carrying request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, ctxRequestID, requestID)
+}
+
+// RequestID returns request ID of the context, or empty string outside of a request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxRequestID).(string)
+	return id
+}
+
+// New creates a logger writing in the configured format and level.
+// Records logged with context get request_id and trace_id attributes
+func New(w io.Writer, conf config.ConfigLog) (*slog.Logger, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(conf.Level))
+	if err != nil {
+		return nil, fmt.Errorf("log level: %v", err)
+	}
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch conf.Format {
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", conf.Format)
+	}
+	return slog.New(&requestHandler{handler}), nil
+}
+
+// Setup makes configured logger the default one, the standard log package writes into it too
+func Setup(w io.Writer, conf config.ConfigLog) error {
+	logger, err := New(w, conf)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// requestHandler adds request ID and trace ID of the record context
+type requestHandler struct {
+	slog.Handler
+}
+
+func (h *requestHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *requestHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &requestHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *requestHandler) WithGroup(name string) slog.Handler {
+	return &requestHandler{h.Handler.WithGroup(name)}
+}
+
+// Text logs user-written text as its length only, post bodies must not get into logs
+func Text(key, text string) slog.Attr {
+	return slog.String(key, fmt.Sprintf("[redacted, %d bytes]", len(text)))
+}
+
+// Author logs author ID as a short hash, so posts of one author can be correlated
+// without exposing the cookie value
+func Author(authorID string) slog.Attr {
+	sum := sha256.Sum256([]byte(authorID))
+	return slog.String("author", hex.EncodeToString(sum[:6]))
+}
@@ -0,0 +1,149 @@
+// Package metrics contains Prometheus metrics of gochan
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gochan"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by entity and result, hit or miss.",
+	}, []string{"entity", "result"})
+
+	cacheFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_failures_total",
+		Help:      "Failed cache updates by entity.",
+	}, []string{"entity"})
+
+	cacheBreakerOpen = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "cache_breaker_open",
+		Help:      "1 while redis is bypassed by the circuit breaker.",
+	})
+
+	panicsRecovered = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_panics_recovered_total",
+		Help:      "Handler panics turned into internal server errors.",
+	})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database call latency by DAC method.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"method"})
+
+	postsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posts_created_total",
+		Help:      "Posts created, including opening posts of threads.",
+	})
+
+	threadsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "threads_created_total",
+		Help:      "Threads created.",
+	})
+
+	imagesUploaded = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "images_uploaded_total",
+		Help:      "Images uploaded, including duplicates of existing ones.",
+	})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestDuration,
+		cacheRequests,
+		cacheFailures,
+		cacheBreakerOpen,
+		panicsRecovered,
+		queryDuration,
+		postsCreated,
+		threadsCreated,
+		imagesUploaded,
+	)
+}
+
+// Handler returns Prometheus scrape handler
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records HTTP request latency of the route
+func ObserveRequest(route, method string, status int, duration time.Duration) {
+	requestDuration.WithLabelValues(route, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// ObserveCache records cache lookup result of the entity
+func ObserveCache(entity string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(entity, result).Inc()
+}
+
+// CacheFailed counts failed cache update of the entity
+func CacheFailed(entity string) {
+	cacheFailures.WithLabelValues(entity).Inc()
+}
+
+// CacheBreaker records circuit breaker state
+func CacheBreaker(open bool) {
+	if open {
+		cacheBreakerOpen.Set(1)
+	} else {
+		cacheBreakerOpen.Set(0)
+	}
+}
+
+// PanicRecovered counts a handler panic
+func PanicRecovered() {
+	panicsRecovered.Inc()
+}
+
+// ObserveQuery records latency of the DAC method started at the given time,
+// it is meant to be deferred at the beginning of the method
+func ObserveQuery(method string, start time.Time) {
+	queryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// PostCreated counts a new post
+func PostCreated() {
+	postsCreated.Inc()
+}
+
+// ThreadCreated counts a new thread
+func ThreadCreated() {
+	threadsCreated.Inc()
+}
+
+// ImageUploaded counts a new image upload
+func ImageUploaded() {
+	imagesUploaded.Inc()
+}
@@ -0,0 +1,206 @@
+package model
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+)
+
+type (
+	// BanKey represents unique ban model key
+	BanKey int
+	// BanAppealKey represents unique ban appeal model key
+	BanAppealKey int
+)
+
+// BanModelDB is a ban model DB interaction interface
+type BanModelDB interface {
+	GetBanList(context.Context) ([]*Ban, error)
+	GetBan(context.Context, BanKey) (*Ban, error)
+	FindBan(ctx context.Context, ip string, author AuthorKey, board BoardKey) (*Ban, error)
+	PutBan(context.Context, Ban) (BanKey, error)
+	LiftBan(context.Context, BanKey) error
+	GetAppealsByBan(context.Context, BanKey) ([]*BanAppeal, error)
+	PutAppeal(context.Context, BanAppeal) (BanAppealKey, error)
+}
+
+// Ban model
+
+// Ban is a db structure of ban table
+type Ban struct {
+	Key                BanKey
+	IP                 *string    // single IP or CIDR, nil for author-only bans
+	Author             *AuthorKey // nil for IP-only bans
+	Board              *BoardKey  // nil for global bans
+	Reason             string
+	CreationDateTime   time.Time
+	ExpirationDateTime *time.Time // nil for permanent bans
+}
+
+// IsActive returns ban activity status at the given time
+func (b *Ban) IsActive(now time.Time) bool {
+	return b.ExpirationDateTime == nil || b.ExpirationDateTime.After(now)
+}
+
+// Matches checks if the ban covers given IP or author
+func (b *Ban) Matches(ip string, author AuthorKey) bool {
+	if b.Author != nil && author != "" && *b.Author == author {
+		return true
+	}
+	if b.IP == nil {
+		return false
+	}
+	clientIP := net.ParseIP(ip)
+	if clientIP == nil {
+		return false
+	}
+	if strings.Contains(*b.IP, "/") {
+		_, network, err := net.ParseCIDR(*b.IP)
+		return err == nil && network.Contains(clientIP)
+	}
+	banIP := net.ParseIP(*b.IP)
+	return banIP != nil && banIP.Equal(clientIP)
+}
+
+// GetBoard returns ban board scope
+func (b *Ban) GetBoard() string {
+	if b.Board != nil {
+		return string(*b.Board)
+	}
+	return ""
+}
+
+// BanError is an error of banned poster, it matches ErrBanned
+type BanError struct {
+	Ban *Ban
+}
+
+func (e *BanError) Error() string {
+	return ErrBanned.Error() + ": " + e.Ban.Reason
+}
+
+// Unwrap returns ErrBanned
+func (e *BanError) Unwrap() error {
+	return ErrBanned
+}
+
+// BanAppeal is a db structure of ban_appeal table
+type BanAppeal struct {
+	Key              BanAppealKey
+	Ban              BanKey
+	Text             string
+	CreationDateTime time.Time
+}
+
+// BanModel is a ban model
+type BanModel struct {
+	repoConnection *RepoHandler
+	modelDAC       BanModelDB
+	modLog         *ModActionModel
+}
+
+// NewBanModel creates new BanModel
+func NewBanModel(repoConnection *RepoHandler, modelDAC BanModelDB, modLog *ModActionModel) *BanModel {
+	return &BanModel{
+		repoConnection: repoConnection,
+		modelDAC:       modelDAC,
+		modLog:         modLog,
+	}
+}
+
+// CheckBan returns active ban for given IP or author on the board,
+// or nil if the poster isn't banned
+func (m *BanModel) CheckBan(ctx context.Context, ip string, author AuthorKey, board BoardKey) (*Ban, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.FindBan(ctx, ip, author, board)
+}
+
+// GetList returns all bans
+func (m *BanModel) GetList(ctx context.Context) ([]*Ban, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.GetBanList(ctx)
+}
+
+// GetBan returns certain ban by key
+func (m *BanModel) GetBan(ctx context.Context, banID BanKey) (*Ban, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.GetBan(ctx, banID)
+}
+
+// PutBan adds new ban into db
+func (m *BanModel) PutBan(ctx context.Context, newBan Ban, mod ModInfo) (BanKey, error) {
+	if newBan.IP != nil {
+		ip := *newBan.IP
+		if net.ParseIP(ip) == nil {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return 0, ErrInvalidBanIP
+			}
+		}
+	}
+	if newBan.IP == nil && newBan.Author == nil {
+		return 0, ErrEmptyBan
+	}
+
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	index, err := m.modelDAC.PutBan(ctx, newBan)
+	if err != nil {
+		return 0, err
+	}
+
+	m.modLog.log(ctx, ModAction{
+		Action: ModBanAdd,
+		Board:  newBan.Board,
+		Ban:    &index,
+	}, mod)
+
+	return index, nil
+}
+
+// LiftBan expires certain ban immediately
+func (m *BanModel) LiftBan(ctx context.Context, banID BanKey, mod ModInfo) error {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	banItem, err := m.modelDAC.GetBan(ctx, banID)
+	if err != nil {
+		return err
+	}
+
+	err = m.modelDAC.LiftBan(ctx, banID)
+	if err != nil {
+		return err
+	}
+
+	m.modLog.log(ctx, ModAction{
+		Action: ModBanLift,
+		Board:  banItem.Board,
+		Ban:    &banItem.Key,
+	}, mod)
+
+	return nil
+}
+
+// GetAppeals returns appeals of certain ban
+func (m *BanModel) GetAppeals(ctx context.Context, banID BanKey) ([]*BanAppeal, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.GetAppealsByBan(ctx, banID)
+}
+
+// PutAppeal adds new ban appeal into db
+func (m *BanModel) PutAppeal(ctx context.Context, newAppeal BanAppeal) (BanAppealKey, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.PutAppeal(ctx, newAppeal)
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+
+	"github.com/ilyakaznacheev/gochan/metrics"
+)
+
+// ErrCacheUnavailable is returned by cache calls while redis is bypassed, models read from db then
+var ErrCacheUnavailable = errors.New("cache is unavailable")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker stops redis calls after consecutive failures,
+// so redis outage doesn't add a timeout to every request.
+// After cooldown a single trial call is let through, and the breaker is closed if it succeeds.
+// Nil breaker lets all calls through
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	// onRecover is called when redis is available again
+	onRecover func()
+
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openUntil time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, onRecover func()) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		onRecover: onRecover,
+	}
+}
+
+// allow reports whether a call may be sent to redis
+func (cb *circuitBreaker) allow() bool {
+	if cb == nil {
+		return true
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == breakerClosed {
+		return true
+	}
+	if time.Now().Before(cb.openUntil) {
+		return false
+	}
+	// the next trial is allowed after another cooldown, in case this one is never sent
+	cb.state = breakerHalfOpen
+	cb.openUntil = time.Now().Add(cb.cooldown)
+	return true
+}
+
+// record counts result of a redis call.
+// Missing keys and cancelled requests are not redis failures
+func (cb *circuitBreaker) record(err error) {
+	if cb == nil {
+		return
+	}
+	failed := err != nil && err != redis.Nil && !errors.Is(err, context.Canceled)
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if !failed {
+		cb.failures = 0
+		if cb.state != breakerClosed {
+			cb.state = breakerClosed
+			metrics.CacheBreaker(false)
+			slog.Info("redis is available, cache is enabled")
+			go cb.onRecover()
+		}
+		return
+	}
+
+	cb.failures++
+	if cb.state == breakerHalfOpen || (cb.state == breakerClosed && cb.failures >= cb.threshold) {
+		if cb.state == breakerClosed {
+			metrics.CacheBreaker(true)
+			slog.Warn("redis is unavailable, cache is bypassed", "cooldown", cb.cooldown.String(), "err", err)
+		}
+		cb.state = breakerOpen
+		cb.openUntil = time.Now().Add(cb.cooldown)
+	}
+}
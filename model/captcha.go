@@ -0,0 +1,90 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-redis/redis"
+	"github.com/google/uuid"
+
+	"github.com/ilyakaznacheev/gochan/captcha"
+	"github.com/ilyakaznacheev/gochan/config"
+)
+
+const (
+	redCaptchaKey       = "captcha"
+	redCaptchaSolvedKey = "captcha-solved"
+)
+
+// CaptchaKey represents unique captcha challenge key
+type CaptchaKey string
+
+// CaptchaModel is a captcha challenge model.
+// Challenges live in redis, or in process memory without it, and expire by TTL
+type CaptchaModel struct {
+	repoConnection *RepoHandler
+	conf           config.ConfigCaptcha
+}
+
+// NewCaptchaModel creates new CaptchaModel
+func NewCaptchaModel(repoConnection *RepoHandler, conf config.ConfigCaptcha) *CaptchaModel {
+	return &CaptchaModel{
+		repoConnection: repoConnection,
+		conf:           conf,
+	}
+}
+
+// NewChallenge creates new challenge and returns its key
+func (m *CaptchaModel) NewChallenge(ctx context.Context) (CaptchaKey, error) {
+	key := CaptchaKey(uuid.New().String())
+	err := m.repoConnection.redis.setTemp(
+		ctx,
+		redCaptchaKey,
+		string(key),
+		captcha.RandomDigits(m.conf.Length),
+		m.conf.TTL,
+	)
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// GetDigits returns challenge digits to render the image
+func (m *CaptchaModel) GetDigits(ctx context.Context, key CaptchaKey) (string, error) {
+	digits, err := m.repoConnection.redis.getTemp(ctx, redCaptchaKey, string(key))
+	if err == redis.Nil {
+		return "", fmt.Errorf("captcha %s: %w", key, ErrNotFound)
+	}
+	return digits, err
+}
+
+// Verify checks the answer. Each challenge can be checked only once
+func (m *CaptchaModel) Verify(ctx context.Context, key CaptchaKey, answer string, author AuthorKey) bool {
+	if key == "" {
+		return false
+	}
+	digits, err := m.repoConnection.redis.takeTemp(ctx, redCaptchaKey, string(key))
+	if err != nil || digits != strings.TrimSpace(answer) {
+		return false
+	}
+
+	if m.conf.SkipDuration > 0 && author != "" {
+		m.repoConnection.redis.setTemp(ctx, redCaptchaSolvedKey, string(author), "1", m.conf.SkipDuration)
+	}
+	return true
+}
+
+// IsRequired checks if the author has to solve a challenge to post on the board
+func (m *CaptchaModel) IsRequired(ctx context.Context, board *Board, author AuthorKey) bool {
+	if !board.Captcha {
+		return false
+	}
+	if m.conf.SkipDuration > 0 && author != "" {
+		if _, err := m.repoConnection.redis.getTemp(ctx, redCaptchaSolvedKey, string(author)); err == nil {
+			return false
+		}
+	}
+	return true
+}
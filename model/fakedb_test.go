@@ -0,0 +1,156 @@
+package model
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// fakeDB is an in-memory DAC returning fixed data and counting getter calls
+type fakeDB struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+var (
+	fakeTime   = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	fakeBoard  = Board{Key: "b", Name: "stored board"}
+	fakeThread = Thread{Key: 1, Title: "stored thread", AuthorID: "author", BoardName: "b", CreationDateTime: fakeTime}
+	fakePost   = Post{Key: 1, Author: "author", Thread: 1, CreationDateTime: fakeTime, Text: "stored post"}
+	fakeAuthor = Author{Key: "author"}
+	fakeFilter = Filter{Key: 1, Pattern: "stored", Action: FilterReject}
+)
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{calls: make(map[string]int)}
+}
+
+func (f *fakeDB) call(method string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[method]++
+}
+
+// count returns number of calls of the method
+func (f *fakeDB) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+func (f *fakeDB) GetBoardList(ctx context.Context) ([]*Board, error) {
+	f.call("GetBoardList")
+	boardItem := fakeBoard
+	return []*Board{&boardItem}, nil
+}
+
+func (f *fakeDB) GetBoard(ctx context.Context, key BoardKey) (*Board, error) {
+	f.call("GetBoard")
+	if key != fakeBoard.Key {
+		return nil, ErrNotFound
+	}
+	boardItem := fakeBoard
+	return &boardItem, nil
+}
+
+func (f *fakeDB) PutBoard(context.Context, Board) error       { return nil }
+func (f *fakeDB) UpdateBoard(context.Context, Board) error    { return nil }
+func (f *fakeDB) DeleteBoard(context.Context, BoardKey) error { return nil }
+
+func (f *fakeDB) GetTheadsByBoard(ctx context.Context, key BoardKey) ([]*Thread, error) {
+	f.call("GetTheadsByBoard")
+	threadItem := fakeThread
+	return []*Thread{&threadItem}, nil
+}
+
+func (f *fakeDB) GetThreadsByAuthor(ctx context.Context, key AuthorKey) ([]*Thread, error) {
+	f.call("GetThreadsByAuthor")
+	threadItem := fakeThread
+	return []*Thread{&threadItem}, nil
+}
+
+func (f *fakeDB) GetThread(ctx context.Context, key ThreadKey) (*Thread, error) {
+	f.call("GetThread")
+	if key != fakeThread.Key {
+		return nil, ErrNotFound
+	}
+	threadItem := fakeThread
+	return &threadItem, nil
+}
+
+func (f *fakeDB) PutThread(context.Context, Thread) (ThreadKey, error) { return 0, nil }
+func (f *fakeDB) CreateThreadWithOP(context.Context, Thread, Post, *Image) (ThreadKey, PostKey, error) {
+	return 0, 0, nil
+}
+func (f *fakeDB) SetThreadFlags(ctx context.Context, key ThreadKey, sticky, locked bool) error {
+	return nil
+}
+
+func (f *fakeDB) GetPostsByThread(ctx context.Context, key ThreadKey) ([]*Post, error) {
+	f.call("GetPostsByThread")
+	postItem := fakePost
+	return []*Post{&postItem}, nil
+}
+
+func (f *fakeDB) GetPostsByAuthor(ctx context.Context, key AuthorKey) ([]*Post, error) {
+	f.call("GetPostsByAuthor")
+	postItem := fakePost
+	return []*Post{&postItem}, nil
+}
+
+func (f *fakeDB) GetPost(ctx context.Context, key PostKey) (*Post, error) {
+	f.call("GetPost")
+	if key != fakePost.Key {
+		return nil, ErrNotFound
+	}
+	postItem := fakePost
+	return &postItem, nil
+}
+
+func (f *fakeDB) GetPostBoard(ctx context.Context, key PostKey) (BoardKey, error) {
+	if key != fakePost.Key {
+		return "", ErrNotFound
+	}
+	return fakeThread.BoardName, nil
+}
+
+func (f *fakeDB) PutPost(context.Context, Post) (PostKey, error) { return 0, nil }
+func (f *fakeDB) DeletePost(context.Context, PostKey) error      { return nil }
+
+func (f *fakeDB) GetAuthor(ctx context.Context, key AuthorKey) (*Author, error) {
+	f.call("GetAuthor")
+	if key != fakeAuthor.Key {
+		return nil, ErrNotFound
+	}
+	authorItem := fakeAuthor
+	return &authorItem, nil
+}
+
+func (f *fakeDB) GetFilterList(ctx context.Context) ([]*Filter, error) {
+	f.call("GetFilterList")
+	filterItem := fakeFilter
+	return []*Filter{&filterItem}, nil
+}
+
+func (f *fakeDB) GetFiltersByBoard(ctx context.Context, key BoardKey) ([]*Filter, error) {
+	f.call("GetFiltersByBoard")
+	filterItem := fakeFilter
+	return []*Filter{&filterItem}, nil
+}
+
+func (f *fakeDB) GetFilter(ctx context.Context, key FilterKey) (*Filter, error) {
+	if key != fakeFilter.Key {
+		return nil, ErrNotFound
+	}
+	filterItem := fakeFilter
+	return &filterItem, nil
+}
+
+func (f *fakeDB) PutFilter(context.Context, Filter) (FilterKey, error) { return 0, nil }
+func (f *fakeDB) DeleteFilter(context.Context, FilterKey) error        { return nil }
+func (f *fakeDB) GetFilterMatchList(ctx context.Context, limit int) ([]*FilterMatch, error) {
+	return nil, nil
+}
+func (f *fakeDB) PutFilterMatch(context.Context, FilterMatch) (FilterMatchKey, error) {
+	return 0, nil
+}
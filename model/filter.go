@@ -0,0 +1,329 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	redFilterBoardKey = "filter-board"
+)
+
+type (
+	// FilterKey represents unique filter model key
+	FilterKey int
+	// FilterMatchKey represents unique filter match model key
+	FilterMatchKey int
+	// FilterAction represents action taken on filter match
+	FilterAction string
+)
+
+// Filter actions
+const (
+	// FilterReplace replaces matched text with filter replacement
+	FilterReplace FilterAction = "replace"
+	// FilterReject rejects the whole post
+	FilterReject FilterAction = "reject"
+	// FilterModerate accepts the post and sends it to moderation queue
+	FilterModerate FilterAction = "moderate"
+)
+
+// FilterModelDB is a filter model DB interaction interface
+type FilterModelDB interface {
+	GetFilterList(context.Context) ([]*Filter, error)
+	GetFiltersByBoard(context.Context, BoardKey) ([]*Filter, error)
+	GetFilter(context.Context, FilterKey) (*Filter, error)
+	PutFilter(context.Context, Filter) (FilterKey, error)
+	DeleteFilter(context.Context, FilterKey) error
+	GetFilterMatchList(ctx context.Context, limit int) ([]*FilterMatch, error)
+	PutFilterMatch(context.Context, FilterMatch) (FilterMatchKey, error)
+}
+
+// Filter model
+
+// Filter is a db structure of filter table
+type Filter struct {
+	Key         FilterKey
+	Board       *BoardKey // nil for global filters
+	Pattern     string
+	IsRegex     bool
+	Action      FilterAction
+	Replacement string
+}
+
+// GetBoard returns filter board scope
+func (f *Filter) GetBoard() string {
+	if f.Board != nil {
+		return string(*f.Board)
+	}
+	return ""
+}
+
+// expression returns filter pattern as a regular expression source
+func (f *Filter) expression() string {
+	if f.IsRegex {
+		return f.Pattern
+	}
+	return "(?i)" + regexp.QuoteMeta(f.Pattern)
+}
+
+// FilterMatch is a db structure of filter_match table
+type FilterMatch struct {
+	Key              FilterMatchKey
+	Filter           FilterKey
+	Action           FilterAction
+	Board            BoardKey
+	Author           AuthorKey
+	Thread           *ThreadKey
+	Post             *PostKey
+	Text             string
+	CreationDateTime time.Time
+}
+
+// FilterResult is a result of filter application
+type FilterResult struct {
+	Matches  []FilterMatch
+	Moderate bool
+}
+
+// FilterModel is a filter model
+type FilterModel struct {
+	repoConnection *RepoHandler
+	modelDAC       FilterModelDB
+	modLog         *ModActionModel
+
+	mu      sync.RWMutex
+	regexps map[string]*regexp.Regexp
+}
+
+// NewFilterModel creates new FilterModel
+func NewFilterModel(repoConnection *RepoHandler, modelDAC FilterModelDB, modLog *ModActionModel) *FilterModel {
+	return &FilterModel{
+		repoConnection: repoConnection,
+		modelDAC:       modelDAC,
+		modLog:         modLog,
+		regexps:        make(map[string]*regexp.Regexp),
+	}
+}
+
+// compile returns compiled filter expression, reusing already compiled ones
+func (m *FilterModel) compile(f *Filter) (*regexp.Regexp, error) {
+	expr := f.expression()
+
+	m.mu.RLock()
+	re, ok := m.regexps[expr]
+	m.mu.RUnlock()
+	if ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.regexps[expr] = re
+	m.mu.Unlock()
+	return re, nil
+}
+
+// GetList returns all filters
+func (m *FilterModel) GetList(ctx context.Context) ([]*Filter, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.GetFilterList(ctx)
+}
+
+// GetFiltersByBoard returns global and board filters
+func (m *FilterModel) GetFiltersByBoard(ctx context.Context, boardName BoardKey) ([]*Filter, error) {
+	var (
+		filterListCache []Filter
+		filterList      []*Filter
+	)
+
+	// read from cache
+	cachedData, err := m.repoConnection.redis.get(ctx, redFilterBoardKey, string(boardName))
+	if err == nil {
+		filterListCache = make([]Filter, 0)
+		json.Unmarshal([]byte(cachedData), &filterListCache)
+
+		for idx := range filterListCache {
+			filterList = append(filterList, &filterListCache[idx])
+		}
+		return filterList, nil
+	}
+
+	// read from db
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	filterList, err = m.modelDAC.GetFiltersByBoard(ctx, boardName)
+	if err != nil {
+		return nil, err
+	}
+
+	// update cache
+	go func(ctx context.Context) {
+		cacheVersion := m.repoConnection.redis.updateChangeCounter(ctx, redFilterBoardKey)
+
+		filterListCache = make([]Filter, 0, len(filterList))
+		for idx := range filterList {
+			filterListCache = append(filterListCache, *filterList[idx])
+		}
+		newCachedData, err := json.Marshal(&filterListCache)
+		if err != nil {
+			m.repoConnection.redis.cacheFailed(ctx, redFilterBoardKey, err)
+			return
+		}
+		err = m.repoConnection.redis.set(
+			ctx,
+			redFilterBoardKey,
+			string(boardName),
+			string(newCachedData),
+			cacheVersion,
+		)
+		if err != nil {
+			m.repoConnection.redis.cacheFailed(ctx, redFilterBoardKey, err)
+		}
+	}(context.WithoutCancel(ctx))
+
+	return filterList, nil
+}
+
+// PutFilter adds new filter into db
+func (m *FilterModel) PutFilter(ctx context.Context, newFilter Filter, mod ModInfo) (FilterKey, error) {
+	switch newFilter.Action {
+	case FilterReplace, FilterReject, FilterModerate:
+	default:
+		return 0, ErrInvalidFilter
+	}
+	if newFilter.Pattern == "" {
+		return 0, ErrInvalidFilter
+	}
+	if _, err := regexp.Compile(newFilter.expression()); err != nil {
+		return 0, ErrInvalidFilter
+	}
+
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	index, err := m.modelDAC.PutFilter(ctx, newFilter)
+	if err != nil {
+		return 0, err
+	}
+
+	mod.Reason = fmt.Sprintf("filter #%d %s %q: %s", index, newFilter.Action, newFilter.Pattern, mod.Reason)
+	m.modLog.log(ctx, ModAction{
+		Action: ModFilterAdd,
+		Board:  newFilter.Board,
+	}, mod)
+
+	// update cache version
+	go m.repoConnection.redis.updateChangeCounter(context.WithoutCancel(ctx), redFilterBoardKey)
+
+	return index, nil
+}
+
+// DeleteFilter removes filter from db
+func (m *FilterModel) DeleteFilter(ctx context.Context, filterID FilterKey, mod ModInfo) error {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	filterItem, err := m.modelDAC.GetFilter(ctx, filterID)
+	if err != nil {
+		return err
+	}
+
+	err = m.modelDAC.DeleteFilter(ctx, filterID)
+	if err != nil {
+		return err
+	}
+
+	mod.Reason = fmt.Sprintf("filter #%d %s %q: %s", filterID, filterItem.Action, filterItem.Pattern, mod.Reason)
+	m.modLog.log(ctx, ModAction{
+		Action: ModFilterDelete,
+		Board:  filterItem.Board,
+	}, mod)
+
+	// update cache version
+	go m.repoConnection.redis.updateChangeCounter(context.WithoutCancel(ctx), redFilterBoardKey)
+
+	return nil
+}
+
+// Apply runs board filters on given texts in place.
+// Rejected posts are logged immediately and ErrFilterRejected is returned,
+// other matches have to be logged with LogMatches after the post is saved
+func (m *FilterModel) Apply(ctx context.Context, boardName BoardKey, authorID AuthorKey, texts ...*string) (*FilterResult, error) {
+	filterList, err := m.GetFiltersByBoard(ctx, boardName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FilterResult{}
+	for _, filterItem := range filterList {
+		re, err := m.compile(filterItem)
+		if err != nil {
+			slog.WarnContext(ctx, "broken filter", "filter", filterItem.Key, "err", err)
+			continue
+		}
+
+		for _, text := range texts {
+			found := re.FindString(*text)
+			if found == "" {
+				continue
+			}
+
+			result.Matches = append(result.Matches, FilterMatch{
+				Filter:           filterItem.Key,
+				Action:           filterItem.Action,
+				Board:            boardName,
+				Author:           authorID,
+				Text:             found,
+				CreationDateTime: time.Now(),
+			})
+
+			switch filterItem.Action {
+			case FilterReplace:
+				*text = re.ReplaceAllLiteralString(*text, filterItem.Replacement)
+			case FilterReject:
+				m.LogMatches(ctx, result, nil, nil)
+				return nil, ErrFilterRejected
+			case FilterModerate:
+				result.Moderate = true
+			}
+		}
+	}
+	return result, nil
+}
+
+// LogMatches saves filter matches for audit.
+// The post is already saved or rejected, so matches are saved even if the request is cancelled
+func (m *FilterModel) LogMatches(ctx context.Context, result *FilterResult, threadID *ThreadKey, postID *PostKey) {
+	ctx, cancel := m.repoConnection.dbContext(context.WithoutCancel(ctx))
+	defer cancel()
+
+	for _, matchItem := range result.Matches {
+		matchItem.Thread = threadID
+		matchItem.Post = postID
+		_, err := m.modelDAC.PutFilterMatch(ctx, matchItem)
+		if err != nil {
+			slog.ErrorContext(ctx, "can't save filter match", "filter", matchItem.Filter, "err", err)
+		}
+	}
+}
+
+// GetMatchList returns latest filter matches
+func (m *FilterModel) GetMatchList(ctx context.Context, limit int) ([]*FilterMatch, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.GetFilterMatchList(ctx, limit)
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	// ModActionKey represents unique moderation action model key
+	ModActionKey int
+	// ModActionType represents kind of moderation action
+	ModActionType string
+)
+
+// Moderation action types
+const (
+	ModBanAdd        ModActionType = "ban.add"
+	ModBanLift       ModActionType = "ban.lift"
+	ModBoardCreate   ModActionType = "board.create"
+	ModBoardDelete   ModActionType = "board.delete"
+	ModBoardUpdate   ModActionType = "board.update"
+	ModFilterAdd     ModActionType = "filter.add"
+	ModFilterDelete  ModActionType = "filter.delete"
+	ModPostDelete    ModActionType = "post.delete"
+	ModReportDismiss ModActionType = "report.dismiss"
+	ModReportReopen  ModActionType = "report.reopen"
+	ModReportResolve ModActionType = "report.resolve"
+	ModThreadLock    ModActionType = "thread.lock"
+	ModThreadSticky  ModActionType = "thread.sticky"
+)
+
+// ModActionModelDB is a moderation action model DB interaction interface.
+// The log is append-only, so there are no update or delete methods
+type ModActionModelDB interface {
+	GetModActions(context.Context, ModActionFilter) ([]*ModAction, error)
+	PutModAction(context.Context, ModAction) (ModActionKey, error)
+}
+
+// ModInfo describes who performs moderation action and why
+type ModInfo struct {
+	Moderator string
+	Reason    string
+}
+
+// ModActionFilter is a moderation log search criteria, empty fields match everything
+type ModActionFilter struct {
+	Moderator string
+	Action    ModActionType
+	Board     BoardKey
+	Since     *time.Time
+	Until     *time.Time
+	Limit     int
+}
+
+// Moderation action model
+
+// ModAction is a db structure of mod_action table
+type ModAction struct {
+	Key              ModActionKey
+	Moderator        string
+	Action           ModActionType
+	Board            *BoardKey
+	Thread           *ThreadKey
+	Post             *PostKey
+	Image            *uuid.UUID
+	Ban              *BanKey
+	Reason           string
+	CreationDateTime time.Time
+}
+
+// ModActionModel is a moderation action model
+type ModActionModel struct {
+	repoConnection *RepoHandler
+	modelDAC       ModActionModelDB
+}
+
+// NewModActionModel creates new ModActionModel
+func NewModActionModel(repoConnection *RepoHandler, modelDAC ModActionModelDB) *ModActionModel {
+	return &ModActionModel{
+		repoConnection: repoConnection,
+		modelDAC:       modelDAC,
+	}
+}
+
+// GetList returns moderation actions matching the filter, newest first
+func (m *ModActionModel) GetList(ctx context.Context, filter ModActionFilter) ([]*ModAction, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.GetModActions(ctx, filter)
+}
+
+// log appends moderation action to the log.
+// Moderation itself has already happened, so failures are only logged
+// and the record is written even if the request is cancelled meanwhile
+func (m *ModActionModel) log(ctx context.Context, action ModAction, mod ModInfo) {
+	action.Moderator = mod.Moderator
+	action.Reason = mod.Reason
+	action.CreationDateTime = time.Now()
+
+	ctx, cancel := m.repoConnection.dbContext(context.WithoutCancel(ctx))
+	defer cancel()
+
+	_, err := m.modelDAC.PutModAction(ctx, action)
+	if err != nil {
+		slog.ErrorContext(ctx, "can't write moderation log", "action", action.Action, "err", err)
+	}
+}
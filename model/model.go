@@ -1,16 +1,27 @@
 package model
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/ilyakaznacheev/gochan/config"
+	"github.com/ilyakaznacheev/gochan/metrics"
+	"github.com/ilyakaznacheev/gochan/tracing"
 )
 
 type (
@@ -35,50 +46,94 @@ const (
 	redThreadBoardKey  = "thread-board"
 	redThreadAuthorKey = "thread-author"
 	redPostKey         = "post-key"
-	redPostAuthorKey   = "post-thread"
+	redPostAuthorKey   = "post-author"
 	redPostThreadKey   = "post-thread"
 	redAuthorKey       = "author-key"
 )
 
+// versionedEntities are cached entities with change counters
+var versionedEntities = []string{
+	redBoardList,
+	redBoardKey,
+	redThreadKey,
+	redThreadBoardKey,
+	redThreadAuthorKey,
+	redPostKey,
+	redPostThreadKey,
+	redPostAuthorKey,
+	redAuthorKey,
+	redFilterBoardKey,
+}
+
 var (
 	// ErrRedisCacheVersion error while redis cache version check
 	ErrRedisCacheVersion = errors.New("cache outdated") //todo: remove
 	ErrCacheOutdated     = errors.New("cache outdated")
+
+	// ErrNotFound error while requested entity doesn't exist
+	ErrNotFound = errors.New("not found")
+	// ErrLocked error while posting into locked thread
+	ErrLocked = errors.New("thread is locked, new posts are not allowed")
+	// ErrBanned error while banned poster tries to post, see BanError
+	ErrBanned = errors.New("you are banned")
+	// ErrRateLimited error while poster sends requests faster than allowed
+	ErrRateLimited = errors.New("too many requests")
+	// ErrValidation error while user input check, more specific errors wrap it
+	ErrValidation = errors.New("invalid input")
+
+	// ErrInvalidBanIP error while ban IP or CIDR parsing
+	ErrInvalidBanIP = fmt.Errorf("%w: invalid ban IP or CIDR", ErrValidation)
+	// ErrEmptyBan error while ban has neither IP nor author
+	ErrEmptyBan = fmt.Errorf("%w: ban must have IP or author", ErrValidation)
+	// ErrCaptchaFailed error while captcha answer check
+	ErrCaptchaFailed = fmt.Errorf("%w: wrong captcha answer", ErrValidation)
+	// ErrFilterRejected error while post is rejected by word filter
+	ErrFilterRejected = fmt.Errorf("%w: post rejected by filter", ErrValidation)
+	// ErrInvalidFilter error while filter validation
+	ErrInvalidFilter = fmt.Errorf("%w: invalid filter pattern or action", ErrValidation)
 )
 
 // DB model interfaces
 
 // BoardModelDB is a board model DB interaction interface
 type BoardModelDB interface {
-	GetBoardList() ([]*Board, error)
-	GetBoard(BoardKey) (*Board, error)
+	GetBoardList(context.Context) ([]*Board, error)
+	GetBoard(context.Context, BoardKey) (*Board, error)
+	PutBoard(context.Context, Board) error
+	UpdateBoard(context.Context, Board) error
+	DeleteBoard(context.Context, BoardKey) error
 }
 
 // ThreadModelDB is a thread model DB interaction interface
 type ThreadModelDB interface {
-	GetTheadsByBoard(BoardKey) ([]*Thread, error)
-	GetThreadsByAuthor(AuthorKey) ([]*Thread, error)
-	GetThread(ThreadKey) (*Thread, error)
-	PutThread(Thread) (ThreadKey, error)
+	GetTheadsByBoard(context.Context, BoardKey) ([]*Thread, error)
+	GetThreadsByAuthor(context.Context, AuthorKey) ([]*Thread, error)
+	GetThread(context.Context, ThreadKey) (*Thread, error)
+	PutThread(context.Context, Thread) (ThreadKey, error)
+	CreateThreadWithOP(context.Context, Thread, Post, *Image) (ThreadKey, PostKey, error)
+	SetThreadFlags(ctx context.Context, key ThreadKey, sticky, locked bool) error
 }
 
 // PostModelDB is a post model DB interaction interface
 type PostModelDB interface {
-	GetPostsByThread(ThreadKey) ([]*Post, error)
-	GetPostsByAuthor(AuthorKey) ([]*Post, error)
-	GetPost(PostKey) (*Post, error)
-	PutPost(Post) (PostKey, error)
+	GetPostsByThread(context.Context, ThreadKey) ([]*Post, error)
+	GetPostsByAuthor(context.Context, AuthorKey) ([]*Post, error)
+	GetPost(context.Context, PostKey) (*Post, error)
+	GetPostBoard(context.Context, PostKey) (BoardKey, error)
+	PutPost(context.Context, Post) (PostKey, error)
+	DeletePost(context.Context, PostKey) error
 }
 
 // ImageModelDB is a image model DB interaction interface
 type ImageModelDB interface {
-	IsImageExist(ImageKey) bool
-	PutImage(*Image) error
+	IsImageExist(context.Context, ImageKey) bool
+	GetImageList(context.Context) ([]*Image, error)
+	PutImage(context.Context, *Image) error
 }
 
 // AuthorModelDB is a author model DB interaction interface
 type AuthorModelDB interface {
-	GetAuthor(AuthorKey) (*Author, error)
+	GetAuthor(context.Context, AuthorKey) (*Author, error)
 }
 
 // Cache model interfaces
@@ -131,29 +186,32 @@ func (key PostKey) String() string {
 
 // Board is a db structure of board table
 type Board struct {
-	Key  BoardKey
-	Name string
+	Key     BoardKey
+	Name    string
+	Captcha bool
 }
 
 // BoardModel is a board model
 type BoardModel struct {
 	repoConnection *RepoHandler
 	modelDAC       BoardModelDB
+	modLog         *ModActionModel
 }
 
 // NewBoardModel creates new BoardModel
-func NewBoardModel(repoConnection *RepoHandler, modelDAC BoardModelDB) *BoardModel {
+func NewBoardModel(repoConnection *RepoHandler, modelDAC BoardModelDB, modLog *ModActionModel) *BoardModel {
 	return &BoardModel{
 		repoConnection: repoConnection,
 		modelDAC:       modelDAC,
+		modLog:         modLog,
 	}
 }
 
 // GetList returns all boards
-func (m *BoardModel) GetList() (boardList []*Board) {
+func (m *BoardModel) GetList(ctx context.Context) (boardList []*Board) {
 	var boardListCache []Board
 	// read from cache
-	cachedData, err := m.repoConnection.redis.get(redBoardList, "")
+	cachedData, err := m.repoConnection.redis.get(ctx, redBoardList, "")
 	if err == nil {
 		boardListCache = make([]Board, 0)
 		json.Unmarshal([]byte(cachedData), &boardListCache)
@@ -166,10 +224,13 @@ func (m *BoardModel) GetList() (boardList []*Board) {
 	}
 
 	// read from db
-	boardList, _ = m.modelDAC.GetBoardList()
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	boardList, _ = m.modelDAC.GetBoardList(ctx)
 
 	// update cache
-	cacheVersion := m.repoConnection.redis.updateChangeCounter(redBoardList)
+	cacheVersion := m.repoConnection.redis.updateChangeCounter(ctx, redBoardList)
 
 	boardListCache = make([]Board, 0, len(boardList))
 	for idx := range boardList {
@@ -177,25 +238,27 @@ func (m *BoardModel) GetList() (boardList []*Board) {
 	}
 	newCachedData, err := json.Marshal(&boardListCache)
 	if err != nil {
-		log.Panic(err)
+		m.repoConnection.redis.cacheFailed(ctx, redBoardList, err)
+		return boardList
 	}
 	err = m.repoConnection.redis.set(
+		ctx,
 		redBoardList,
 		"",
 		string(newCachedData),
 		cacheVersion,
 	)
 	if err != nil {
-		log.Panic(err)
+		m.repoConnection.redis.cacheFailed(ctx, redBoardList, err)
 	}
 
 	return boardList
 }
 
 // GetItem returns certain board by key
-func (m *BoardModel) GetItem(name BoardKey) (*Board, error) {
+func (m *BoardModel) GetItem(ctx context.Context, name BoardKey) (*Board, error) {
 	// read from cache
-	cachedData, err := m.repoConnection.redis.get(redBoardKey, string(name))
+	cachedData, err := m.repoConnection.redis.get(ctx, redBoardKey, string(name))
 	if err == nil {
 		boardCache := &Board{}
 		json.Unmarshal([]byte(cachedData), boardCache)
@@ -204,33 +267,106 @@ func (m *BoardModel) GetItem(name BoardKey) (*Board, error) {
 	}
 
 	// read from db
-	boardItem, err := m.modelDAC.GetBoard(name)
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	boardItem, err := m.modelDAC.GetBoard(ctx, name)
 	if err != nil {
 		return nil, err
 	}
 
 	// update cache
-	go func() {
-		cacheVersion := m.repoConnection.redis.updateChangeCounter(redBoardKey)
+	go func(ctx context.Context) {
+		cacheVersion := m.repoConnection.redis.updateChangeCounter(ctx, redBoardKey)
 
 		newCachedData, err := json.Marshal(boardItem)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redBoardKey, err)
+			return
 		}
 		err = m.repoConnection.redis.set(
+			ctx,
 			redBoardKey,
 			string(name),
 			string(newCachedData),
 			cacheVersion,
 		)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redBoardKey, err)
 		}
-	}()
+	}(context.WithoutCancel(ctx))
 
 	return boardItem, nil
 }
 
+// UpdateBoard updates board settings
+func (m *BoardModel) UpdateBoard(ctx context.Context, board Board, mod ModInfo) error {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	err := m.modelDAC.UpdateBoard(ctx, board)
+	if err != nil {
+		return err
+	}
+
+	m.modLog.log(ctx, ModAction{
+		Action: ModBoardUpdate,
+		Board:  &board.Key,
+	}, mod)
+
+	// update cache version
+	go func(ctx context.Context) {
+		m.repoConnection.redis.updateChangeCounter(ctx, redBoardList)
+		m.repoConnection.redis.updateChangeCounter(ctx, redBoardKey)
+	}(context.WithoutCancel(ctx))
+
+	return nil
+}
+
+// CreateBoard adds new board
+func (m *BoardModel) CreateBoard(ctx context.Context, board Board, mod ModInfo) error {
+	if board.Key == "" || board.Name == "" {
+		return fmt.Errorf("%w: board key and name are required", ErrValidation)
+	}
+
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	err := m.modelDAC.PutBoard(ctx, board)
+	if err != nil {
+		return err
+	}
+
+	m.modLog.log(ctx, ModAction{
+		Action: ModBoardCreate,
+		Board:  &board.Key,
+	}, mod)
+
+	m.repoConnection.redis.updateChangeCounter(ctx, redBoardList)
+	return nil
+}
+
+// DeleteBoard deletes board with all its threads and posts
+func (m *BoardModel) DeleteBoard(ctx context.Context, name BoardKey, mod ModInfo) error {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	err := m.modelDAC.DeleteBoard(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	m.modLog.log(ctx, ModAction{
+		Action: ModBoardDelete,
+		Board:  &name,
+	}, mod)
+
+	for _, entity := range versionedEntities {
+		m.repoConnection.redis.updateChangeCounter(ctx, entity)
+	}
+	return nil
+}
+
 // Thread model
 
 // Thread is a db structure of thread table
@@ -242,6 +378,8 @@ type Thread struct {
 	CreationDateTime time.Time
 	ImageKey         *uuid.UUID //sql.NullString
 	ImagePath        *string
+	Sticky           bool
+	Locked           bool
 }
 
 // GetImagePath returns path to image
@@ -256,25 +394,27 @@ func (t *Thread) GetImagePath() string {
 type ThreadModel struct {
 	repoConnection *RepoHandler
 	modelDAC       ThreadModelDB
+	modLog         *ModActionModel
 }
 
 // NewThreadModel creates new ThreadModel
-func NewThreadModel(repoConnection *RepoHandler, modelDAC ThreadModelDB) *ThreadModel {
+func NewThreadModel(repoConnection *RepoHandler, modelDAC ThreadModelDB, modLog *ModActionModel) *ThreadModel {
 	return &ThreadModel{
 		repoConnection: repoConnection,
 		modelDAC:       modelDAC,
+		modLog:         modLog,
 	}
 }
 
 // GetTheadsByBoard returns threads by certain board
-func (m *ThreadModel) GetTheadsByBoard(boardName BoardKey) ([]*Thread, error) {
+func (m *ThreadModel) GetTheadsByBoard(ctx context.Context, boardName BoardKey) ([]*Thread, error) {
 	var (
 		threadListCache []Thread
 		threadList      []*Thread
 	)
 
 	// read from cache
-	cachedData, err := m.repoConnection.redis.get(redThreadBoardKey, string(boardName))
+	cachedData, err := m.repoConnection.redis.get(ctx, redThreadBoardKey, string(boardName))
 	if err == nil {
 		threadListCache = make([]Thread, 0)
 		json.Unmarshal([]byte(cachedData), &threadListCache)
@@ -286,14 +426,17 @@ func (m *ThreadModel) GetTheadsByBoard(boardName BoardKey) ([]*Thread, error) {
 	}
 
 	// read from db
-	threadList, err = m.modelDAC.GetTheadsByBoard(boardName)
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	threadList, err = m.modelDAC.GetTheadsByBoard(ctx, boardName)
 	if err != nil {
 		return nil, err
 	}
 
 	// update cache
-	go func() {
-		cacheVersion := m.repoConnection.redis.updateChangeCounter(redThreadBoardKey)
+	go func(ctx context.Context) {
+		cacheVersion := m.repoConnection.redis.updateChangeCounter(ctx, redThreadBoardKey)
 
 		threadListCache = make([]Thread, 0, len(threadList))
 		for idx := range threadList {
@@ -301,31 +444,33 @@ func (m *ThreadModel) GetTheadsByBoard(boardName BoardKey) ([]*Thread, error) {
 		}
 		newCachedData, err := json.Marshal(&threadListCache)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redThreadBoardKey, err)
+			return
 		}
 		err = m.repoConnection.redis.set(
+			ctx,
 			redThreadBoardKey,
 			string(boardName),
 			string(newCachedData),
 			cacheVersion,
 		)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redThreadBoardKey, err)
 		}
-	}()
+	}(context.WithoutCancel(ctx))
 
 	return threadList, nil
 }
 
 // GetThreadsByAuthor returns threads by certain author
-func (m *ThreadModel) GetThreadsByAuthor(authorID AuthorKey) ([]*Thread, error) {
+func (m *ThreadModel) GetThreadsByAuthor(ctx context.Context, authorID AuthorKey) ([]*Thread, error) {
 	var (
 		threadListCache []Thread
 		threadList      []*Thread
 	)
 
 	// read from cache
-	cachedData, err := m.repoConnection.redis.get(redThreadAuthorKey, string(authorID))
+	cachedData, err := m.repoConnection.redis.get(ctx, redThreadAuthorKey, string(authorID))
 	if err == nil {
 		threadListCache = make([]Thread, 0)
 		json.Unmarshal([]byte(cachedData), &threadListCache)
@@ -337,14 +482,17 @@ func (m *ThreadModel) GetThreadsByAuthor(authorID AuthorKey) ([]*Thread, error)
 	}
 
 	// read from db
-	threadList, err = m.modelDAC.GetThreadsByAuthor(authorID)
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	threadList, err = m.modelDAC.GetThreadsByAuthor(ctx, authorID)
 	if err != nil {
 		return nil, err
 	}
 
 	// update cache
-	go func() {
-		cacheVersion := m.repoConnection.redis.updateChangeCounter(redThreadAuthorKey)
+	go func(ctx context.Context) {
+		cacheVersion := m.repoConnection.redis.updateChangeCounter(ctx, redThreadAuthorKey)
 
 		threadListCache = make([]Thread, 0, len(threadList))
 		for idx := range threadList {
@@ -352,27 +500,29 @@ func (m *ThreadModel) GetThreadsByAuthor(authorID AuthorKey) ([]*Thread, error)
 		}
 		newCachedData, err := json.Marshal(&threadListCache)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redThreadAuthorKey, err)
+			return
 		}
 		err = m.repoConnection.redis.set(
+			ctx,
 			redThreadAuthorKey,
 			string(authorID),
 			string(newCachedData),
 			cacheVersion,
 		)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redThreadAuthorKey, err)
 		}
-	}()
+	}(context.WithoutCancel(ctx))
 
 	return threadList, nil
 }
 
 // GetThread returns certain thread by key
-func (m *ThreadModel) GetThread(threadID ThreadKey) (*Thread, error) {
+func (m *ThreadModel) GetThread(ctx context.Context, threadID ThreadKey) (*Thread, error) {
 	var threadCache *Thread
 	// read from cache
-	cachedData, err := m.repoConnection.redis.get(redThreadKey, threadID.String())
+	cachedData, err := m.repoConnection.redis.get(ctx, redThreadKey, threadID.String())
 	if err == nil {
 		threadCache = &Thread{}
 		json.Unmarshal([]byte(cachedData), threadCache)
@@ -381,50 +531,140 @@ func (m *ThreadModel) GetThread(threadID ThreadKey) (*Thread, error) {
 	}
 
 	// read from db
-	threadItem, err := m.modelDAC.GetThread(threadID)
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	threadItem, err := m.modelDAC.GetThread(ctx, threadID)
 	if err != nil {
 		return nil, err
 	}
 
 	// update cache
-	go func() {
-		cacheVersion := m.repoConnection.redis.updateChangeCounter(redThreadKey)
+	go func(ctx context.Context) {
+		cacheVersion := m.repoConnection.redis.updateChangeCounter(ctx, redThreadKey)
 
 		newCachedData, err := json.Marshal(threadItem)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redThreadKey, err)
+			return
 		}
 		err = m.repoConnection.redis.set(
+			ctx,
 			redThreadKey,
 			threadID.String(),
 			string(newCachedData),
 			cacheVersion,
 		)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redThreadKey, err)
 		}
-	}()
+	}(context.WithoutCancel(ctx))
 
 	return threadItem, nil
 }
 
 // PutThread adds new post into db
-func (m *ThreadModel) PutThread(newThread Thread) (ThreadKey, error) {
-	index, err := m.modelDAC.PutThread(newThread)
+func (m *ThreadModel) PutThread(ctx context.Context, newThread Thread) (ThreadKey, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	index, err := m.modelDAC.PutThread(ctx, newThread)
 	if err != nil {
 		return 0, err
 	}
+	metrics.ThreadCreated()
 
 	// update cache version
-	go func() {
-		m.repoConnection.redis.updateChangeCounter(redThreadBoardKey)
-		m.repoConnection.redis.updateChangeCounter(redThreadAuthorKey)
-		m.repoConnection.redis.updateChangeCounter(redThreadKey)
-	}()
+	go func(ctx context.Context) {
+		m.repoConnection.redis.updateChangeCounter(ctx, redThreadBoardKey)
+		m.repoConnection.redis.updateChangeCounter(ctx, redThreadAuthorKey)
+		m.repoConnection.redis.updateChangeCounter(ctx, redThreadKey)
+	}(context.WithoutCancel(ctx))
 
 	return index, nil
 }
 
+// CreateThreadWithOP adds new thread with its opening post and image atomically.
+// Image may be nil, otherwise it is referenced by both thread and post
+func (m *ThreadModel) CreateThreadWithOP(ctx context.Context, newThread Thread, newPost Post, newImage *Image) (ThreadKey, PostKey, error) {
+	if newImage != nil {
+		imageKey := uuid.UUID(newImage.Key)
+		newThread.ImageKey = &imageKey
+		newPost.ImageKey = &imageKey
+	}
+
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	threadIndex, postIndex, err := m.modelDAC.CreateThreadWithOP(ctx, newThread, newPost, newImage)
+	if err != nil {
+		return 0, 0, err
+	}
+	metrics.ThreadCreated()
+	metrics.PostCreated()
+
+	// update cache version
+	go func(ctx context.Context) {
+		m.repoConnection.redis.updateChangeCounter(ctx, redThreadBoardKey)
+		m.repoConnection.redis.updateChangeCounter(ctx, redThreadAuthorKey)
+		m.repoConnection.redis.updateChangeCounter(ctx, redThreadKey)
+		m.repoConnection.redis.updateChangeCounter(ctx, redPostAuthorKey)
+		m.repoConnection.redis.updateChangeCounter(ctx, redPostThreadKey)
+		m.repoConnection.redis.updateChangeCounter(ctx, redPostKey)
+	}(context.WithoutCancel(ctx))
+
+	return threadIndex, postIndex, nil
+}
+
+// SetSticky pins or unpins the thread on top of the board
+func (m *ThreadModel) SetSticky(ctx context.Context, threadID ThreadKey, sticky bool, mod ModInfo) error {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	threadItem, err := m.modelDAC.GetThread(ctx, threadID)
+	if err != nil {
+		return err
+	}
+	return m.setFlags(ctx, threadItem, sticky, threadItem.Locked, ModThreadSticky, mod)
+}
+
+// SetLocked locks or unlocks the thread for new posts
+func (m *ThreadModel) SetLocked(ctx context.Context, threadID ThreadKey, locked bool, mod ModInfo) error {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	threadItem, err := m.modelDAC.GetThread(ctx, threadID)
+	if err != nil {
+		return err
+	}
+	return m.setFlags(ctx, threadItem, threadItem.Sticky, locked, ModThreadLock, mod)
+}
+
+func (m *ThreadModel) setFlags(ctx context.Context, threadItem *Thread, sticky, locked bool, actionType ModActionType, mod ModInfo) error {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	err := m.modelDAC.SetThreadFlags(ctx, threadItem.Key, sticky, locked)
+	if err != nil {
+		return err
+	}
+
+	// update cache version before returning,
+	// so posting into just locked thread isn't allowed by stale cache
+	m.repoConnection.redis.updateChangeCounter(ctx, redThreadBoardKey)
+	m.repoConnection.redis.updateChangeCounter(ctx, redThreadAuthorKey)
+	m.repoConnection.redis.updateChangeCounter(ctx, redThreadKey)
+
+	mod.Reason = fmt.Sprintf("sticky %t, locked %t: %s", sticky, locked, mod.Reason)
+	m.modLog.log(ctx, ModAction{
+		Action: actionType,
+		Board:  &threadItem.BoardName,
+		Thread: &threadItem.Key,
+	}, mod)
+
+	return nil
+}
+
 // Post is a db structure of post table
 type Post struct {
 	Key              PostKey
@@ -448,25 +688,27 @@ func (p *Post) GetImagePath() string {
 type PostModel struct {
 	repoConnection *RepoHandler
 	modelDAC       PostModelDB
+	modLog         *ModActionModel
 }
 
 // NewPostModel creates new PostModel
-func NewPostModel(repoConnection *RepoHandler, modelDAC PostModelDB) *PostModel {
+func NewPostModel(repoConnection *RepoHandler, modelDAC PostModelDB, modLog *ModActionModel) *PostModel {
 	return &PostModel{
 		repoConnection: repoConnection,
 		modelDAC:       modelDAC,
+		modLog:         modLog,
 	}
 }
 
 // GetPostsByThread returns posts by certain thread
-func (m *PostModel) GetPostsByThread(threadID ThreadKey) ([]*Post, error) {
+func (m *PostModel) GetPostsByThread(ctx context.Context, threadID ThreadKey) ([]*Post, error) {
 	var (
 		postListCache []Post
 		postList      []*Post
 	)
 
 	// read from cache
-	cachedData, err := m.repoConnection.redis.get(redPostThreadKey, threadID.String())
+	cachedData, err := m.repoConnection.redis.get(ctx, redPostThreadKey, threadID.String())
 	if err == nil {
 		postListCache = make([]Post, 0)
 		json.Unmarshal([]byte(cachedData), &postListCache)
@@ -478,14 +720,17 @@ func (m *PostModel) GetPostsByThread(threadID ThreadKey) ([]*Post, error) {
 	}
 
 	// read from db
-	postList, err = m.modelDAC.GetPostsByThread(threadID)
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	postList, err = m.modelDAC.GetPostsByThread(ctx, threadID)
 	if err != nil {
 		return nil, err
 	}
 
 	// update cache
-	go func() {
-		cacheVersion := m.repoConnection.redis.updateChangeCounter(redPostThreadKey)
+	go func(ctx context.Context) {
+		cacheVersion := m.repoConnection.redis.updateChangeCounter(ctx, redPostThreadKey)
 
 		postListCache = make([]Post, 0, len(postList))
 		for idx := range postList {
@@ -493,31 +738,33 @@ func (m *PostModel) GetPostsByThread(threadID ThreadKey) ([]*Post, error) {
 		}
 		newCachedData, err := json.Marshal(&postListCache)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redPostThreadKey, err)
+			return
 		}
 		err = m.repoConnection.redis.set(
+			ctx,
 			redPostThreadKey,
 			threadID.String(),
 			string(newCachedData),
 			cacheVersion,
 		)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redPostThreadKey, err)
 		}
-	}()
+	}(context.WithoutCancel(ctx))
 
 	return postList, nil
 }
 
 // GetPostsByAuthor returns posts by certain author
-func (m *PostModel) GetPostsByAuthor(AuthorID AuthorKey) ([]*Post, error) {
+func (m *PostModel) GetPostsByAuthor(ctx context.Context, AuthorID AuthorKey) ([]*Post, error) {
 	var (
 		postListCache []Post
 		postList      []*Post
 	)
 
 	// read from cache
-	cachedData, err := m.repoConnection.redis.get(redPostAuthorKey, string(AuthorID))
+	cachedData, err := m.repoConnection.redis.get(ctx, redPostAuthorKey, string(AuthorID))
 	if err == nil {
 		postListCache = make([]Post, 0)
 		json.Unmarshal([]byte(cachedData), &postListCache)
@@ -529,14 +776,17 @@ func (m *PostModel) GetPostsByAuthor(AuthorID AuthorKey) ([]*Post, error) {
 	}
 
 	// read from db
-	postList, err = m.modelDAC.GetPostsByAuthor(AuthorID)
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	postList, err = m.modelDAC.GetPostsByAuthor(ctx, AuthorID)
 	if err != nil {
 		return nil, err
 	}
 
 	// update cache
-	go func() {
-		cacheVersion := m.repoConnection.redis.updateChangeCounter(redPostAuthorKey)
+	go func(ctx context.Context) {
+		cacheVersion := m.repoConnection.redis.updateChangeCounter(ctx, redPostAuthorKey)
 
 		postListCache = make([]Post, 0, len(postList))
 		for idx := range postList {
@@ -544,27 +794,29 @@ func (m *PostModel) GetPostsByAuthor(AuthorID AuthorKey) ([]*Post, error) {
 		}
 		newCachedData, err := json.Marshal(&postListCache)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redPostAuthorKey, err)
+			return
 		}
 		err = m.repoConnection.redis.set(
+			ctx,
 			redPostAuthorKey,
 			string(AuthorID),
 			string(newCachedData),
 			cacheVersion,
 		)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redPostAuthorKey, err)
 		}
-	}()
+	}(context.WithoutCancel(ctx))
 
 	return postList, nil
 }
 
 // GetPost certain returns post by key
-func (m *PostModel) GetPost(postID PostKey) (*Post, error) {
+func (m *PostModel) GetPost(ctx context.Context, postID PostKey) (*Post, error) {
 	var postCache *Post
 	// read from cache
-	cachedData, err := m.repoConnection.redis.get(redPostKey, postID.String())
+	cachedData, err := m.repoConnection.redis.get(ctx, redPostKey, postID.String())
 	if err == nil {
 		postCache = &Post{}
 		json.Unmarshal([]byte(cachedData), postCache)
@@ -573,49 +825,94 @@ func (m *PostModel) GetPost(postID PostKey) (*Post, error) {
 	}
 
 	// read from db
-	postItem, err := m.modelDAC.GetPost(postID)
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	postItem, err := m.modelDAC.GetPost(ctx, postID)
 	if err != nil {
 		return nil, err
 	}
 
 	// update cache
-	go func() {
-		cacheVersion := m.repoConnection.redis.updateChangeCounter(redPostKey)
+	go func(ctx context.Context) {
+		cacheVersion := m.repoConnection.redis.updateChangeCounter(ctx, redPostKey)
 
 		newCachedData, err := json.Marshal(postItem)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redPostKey, err)
+			return
 		}
 		err = m.repoConnection.redis.set(
+			ctx,
 			redPostKey,
 			postID.String(),
 			string(newCachedData),
 			cacheVersion,
 		)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redPostKey, err)
 		}
-	}()
+	}(context.WithoutCancel(ctx))
 
 	return postItem, nil
 }
 
 // PutPost adds new post into db
-func (m *PostModel) PutPost(newPost Post) (PostKey, error) {
-	index, err := m.modelDAC.PutPost(newPost)
+func (m *PostModel) PutPost(ctx context.Context, newPost Post) (PostKey, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	index, err := m.modelDAC.PutPost(ctx, newPost)
 	if err != nil {
 		return 0, err
 	}
+	metrics.PostCreated()
 
-	go func() {
-		m.repoConnection.redis.updateChangeCounter(redPostAuthorKey)
-		m.repoConnection.redis.updateChangeCounter(redPostThreadKey)
-		m.repoConnection.redis.updateChangeCounter(redPostKey)
-	}()
+	go func(ctx context.Context) {
+		m.repoConnection.redis.updateChangeCounter(ctx, redPostAuthorKey)
+		m.repoConnection.redis.updateChangeCounter(ctx, redPostThreadKey)
+		m.repoConnection.redis.updateChangeCounter(ctx, redPostKey)
+	}(context.WithoutCancel(ctx))
 
 	return index, nil
 }
 
+// DeletePost removes post from db
+func (m *PostModel) DeletePost(ctx context.Context, postID PostKey, mod ModInfo) error {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	postItem, err := m.modelDAC.GetPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+	boardName, err := m.modelDAC.GetPostBoard(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	err = m.modelDAC.DeletePost(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	m.modLog.log(ctx, ModAction{
+		Action: ModPostDelete,
+		Board:  &boardName,
+		Thread: &postItem.Thread,
+		Post:   &postItem.Key,
+		Image:  postItem.ImageKey,
+	}, mod)
+
+	go func(ctx context.Context) {
+		m.repoConnection.redis.updateChangeCounter(ctx, redPostAuthorKey)
+		m.repoConnection.redis.updateChangeCounter(ctx, redPostThreadKey)
+		m.repoConnection.redis.updateChangeCounter(ctx, redPostKey)
+	}(context.WithoutCancel(ctx))
+
+	return nil
+}
+
 // Author model
 
 // Author is a db structure of author table
@@ -639,10 +936,10 @@ func NewAuthorModel(repoConnection *RepoHandler, modelDAC AuthorModelDB) *Author
 }
 
 // GetAuthor returns author data
-func (m *AuthorModel) GetAuthor(authorID AuthorKey) (*Author, error) {
+func (m *AuthorModel) GetAuthor(ctx context.Context, authorID AuthorKey) (*Author, error) {
 	var authorCache *Author
 	// read from cache
-	cachedData, err := m.repoConnection.redis.get(redAuthorKey, string(authorID))
+	cachedData, err := m.repoConnection.redis.get(ctx, redAuthorKey, string(authorID))
 	if err == nil {
 		authorCache = &Author{}
 		json.Unmarshal([]byte(cachedData), authorCache)
@@ -651,29 +948,34 @@ func (m *AuthorModel) GetAuthor(authorID AuthorKey) (*Author, error) {
 	}
 
 	// read from db
-	authorItem, err := m.modelDAC.GetAuthor(authorID)
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	authorItem, err := m.modelDAC.GetAuthor(ctx, authorID)
 	if err != nil {
 		return nil, err
 	}
 
 	// update cache
-	go func() {
-		cacheVersion := m.repoConnection.redis.updateChangeCounter(redAuthorKey)
+	go func(ctx context.Context) {
+		cacheVersion := m.repoConnection.redis.updateChangeCounter(ctx, redAuthorKey)
 
 		newCachedData, err := json.Marshal(authorItem)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redAuthorKey, err)
+			return
 		}
 		err = m.repoConnection.redis.set(
+			ctx,
 			redAuthorKey,
 			string(authorID),
 			string(newCachedData),
 			cacheVersion,
 		)
 		if err != nil {
-			log.Panic(err)
+			m.repoConnection.redis.cacheFailed(ctx, redAuthorKey, err)
 		}
-	}()
+	}(context.WithoutCancel(ctx))
 
 	return authorItem, nil
 }
@@ -699,13 +1001,27 @@ func NewImageModel(repoConnection *RepoHandler, modelDAC ImageModelDB) *ImageMod
 }
 
 // IsImageExist returns image existance status
-func (m *ImageModel) IsImageExist(image ImageKey) bool {
-	return m.modelDAC.IsImageExist(image)
+func (m *ImageModel) IsImageExist(ctx context.Context, image ImageKey) bool {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.IsImageExist(ctx, image)
+}
+
+// GetList returns all images
+func (m *ImageModel) GetList(ctx context.Context) ([]*Image, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.GetImageList(ctx)
 }
 
 // PutImage adds new image into table
-func (m *ImageModel) PutImage(newImage *Image) error {
-	return m.modelDAC.PutImage(newImage)
+func (m *ImageModel) PutImage(ctx context.Context, newImage *Image) error {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.PutImage(ctx, newImage)
 }
 
 // Redis
@@ -716,29 +1032,102 @@ type RedisContainer struct {
 	Content string
 }
 
+// redisClient is a cache client. Without redis the client is nil,
+// then cache calls fail with ErrCacheUnavailable and temporary values are kept in temp store
 type redisClient struct {
-	client *redis.Client
+	client  redis.UniversalClient
+	timeout time.Duration
+	breaker *circuitBreaker
+	temp    *tempStore
 	// input  chan redisAction
 	// finish context.CancelFunc
 }
 
-func (rc *redisClient) get(entity, key string) (string, error) {
-	version, _ := rc.getChangeCounter(entity)
+// withContext returns client bound to the context limited by redis timeout.
+// Closed context and open circuit breaker are reported before the command is sent
+func (rc *redisClient) withContext(ctx context.Context) (redis.Cmdable, context.CancelFunc, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, func() {}, err
+	}
+	if rc.client == nil || !rc.breaker.allow() {
+		return nil, func() {}, ErrCacheUnavailable
+	}
+	cancel := context.CancelFunc(func() {})
+	if rc.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, rc.timeout)
+	}
+	switch client := rc.client.(type) {
+	case *redis.Client:
+		return client.WithContext(ctx), cancel, nil
+	case *redis.ClusterClient:
+		return client.WithContext(ctx), cancel, nil
+	}
+	return rc.client, cancel, nil
+}
+
+// startSpan starts span of redis command on the cache entity
+func (rc *redisClient) startSpan(ctx context.Context, command, entity string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "redis "+command,
+		attribute.String("db.system", "redis"),
+		tracing.CacheEntityKey.String(entity),
+	)
+}
+
+// observeCache records cache lookup result in metrics and the span
+func observeCache(span trace.Span, entity string, hit bool) {
+	metrics.ObserveCache(entity, hit)
+	span.SetAttributes(tracing.CacheHitKey.Bool(hit))
+}
+
+// cacheFailed logs and counts failed cache update.
+// The data is read from db anyway, so the failure doesn't break the request
+func (rc *redisClient) cacheFailed(ctx context.Context, entity string, err error) {
+	if errors.Is(err, ErrCacheUnavailable) {
+		// the breaker has already reported redis outage
+		return
+	}
+	metrics.CacheFailed(entity)
+	slog.WarnContext(ctx, "can't update cache", "entity", entity, "err", err)
+}
+
+func (rc *redisClient) get(ctx context.Context, entity, key string) (string, error) {
+	ctx, span := rc.startSpan(ctx, "GET", entity)
+	defer span.End()
+
+	client, cancel, err := rc.withContext(ctx)
+	defer cancel()
+	if err != nil {
+		return "", err
+	}
+
+	version, _ := rc.getChangeCounter(ctx, entity)
 	entityKey := fmt.Sprintf("%s:%s:%s", redisKey, entity, key)
-	responseData, err := rc.client.Get(entityKey).Result()
+	responseData, err := client.Get(entityKey).Result()
 	if err != nil {
+		observeCache(span, entity, false)
 		return "", err
 	}
 
 	container := &RedisContainer{}
 	json.Unmarshal([]byte(responseData), container)
 	if version > container.Version {
+		observeCache(span, entity, false)
 		return "", ErrRedisCacheVersion
 	}
+	observeCache(span, entity, true)
 	return container.Content, nil
 }
 
-func (rc *redisClient) set(entity, Key, requestData string, version int) error {
+func (rc *redisClient) set(ctx context.Context, entity, Key, requestData string, version int) error {
+	ctx, span := rc.startSpan(ctx, "SET", entity)
+	defer span.End()
+
+	client, cancel, err := rc.withContext(ctx)
+	defer cancel()
+	if err != nil {
+		return err
+	}
+
 	entityKey := fmt.Sprintf("%s:%s:%s", redisKey, entity, Key)
 
 	container := &RedisContainer{
@@ -747,57 +1136,351 @@ func (rc *redisClient) set(entity, Key, requestData string, version int) error {
 	}
 	requestJSON, err := json.Marshal(container)
 	if err != nil {
-		log.Panic(err)
+		return err
 	}
-	err = rc.client.Set(entityKey, string(requestJSON), 0).Err()
+	err = client.Set(entityKey, string(requestJSON), 0).Err()
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (rc *redisClient) updateChangeCounter(entity string) int {
+func (rc *redisClient) setTemp(ctx context.Context, entity, key, value string, ttl time.Duration) error {
+	entityKey := fmt.Sprintf("%s:%s:%s", redisKey, entity, key)
+	if rc.client == nil {
+		rc.temp.set(entityKey, value, ttl)
+		return nil
+	}
+
+	ctx, span := rc.startSpan(ctx, "SET", entity)
+	defer span.End()
+
+	client, cancel, err := rc.withContext(ctx)
+	defer cancel()
+	if err != nil {
+		return err
+	}
+
+	return client.Set(entityKey, value, ttl).Err()
+}
+
+func (rc *redisClient) getTemp(ctx context.Context, entity, key string) (string, error) {
+	entityKey := fmt.Sprintf("%s:%s:%s", redisKey, entity, key)
+	if rc.client == nil {
+		return rc.temp.get(entityKey)
+	}
+
+	ctx, span := rc.startSpan(ctx, "GET", entity)
+	defer span.End()
+
+	client, cancel, err := rc.withContext(ctx)
+	defer cancel()
+	if err != nil {
+		return "", err
+	}
+
+	return client.Get(entityKey).Result()
+}
+
+// takeTemp reads and deletes temporary value in one transaction
+func (rc *redisClient) takeTemp(ctx context.Context, entity, key string) (string, error) {
+	entityKey := fmt.Sprintf("%s:%s:%s", redisKey, entity, key)
+	if rc.client == nil {
+		return rc.temp.take(entityKey)
+	}
+
+	ctx, span := rc.startSpan(ctx, "GETDEL", entity)
+	defer span.End()
+
+	client, cancel, err := rc.withContext(ctx)
+	defer cancel()
+	if err != nil {
+		return "", err
+	}
+
+	pipe := client.TxPipeline()
+	get := pipe.Get(entityKey)
+	pipe.Del(entityKey)
+	_, err = pipe.Exec()
+	if err != nil {
+		return "", err
+	}
+	return get.Val(), nil
+}
+
+func (rc *redisClient) updateChangeCounter(ctx context.Context, entity string) int {
+	ctx, span := rc.startSpan(ctx, "INCR", entity)
+	defer span.End()
+
+	client, cancel, err := rc.withContext(ctx)
+	defer cancel()
+	if err != nil {
+		rc.cacheFailed(ctx, entity, err)
+		return 0
+	}
+
 	entityKey := fmt.Sprintf("%s:%s:%s", redisKey, entity, redChangeKey)
-	counter, err := rc.client.Incr(entityKey).Result()
+	counter, err := client.Incr(entityKey).Result()
 	if err != nil {
-		log.Println(err)
+		rc.cacheFailed(ctx, entity, err)
 	}
 
 	return int(counter)
 }
 
-func (rc *redisClient) getChangeCounter(entity string) (int, error) {
+func (rc *redisClient) getChangeCounter(ctx context.Context, entity string) (int, error) {
+	ctx, span := rc.startSpan(ctx, "GET", entity)
+	defer span.End()
+
+	client, cancel, err := rc.withContext(ctx)
+	defer cancel()
+	if err != nil {
+		return 0, err
+	}
+
 	entityKey := fmt.Sprintf("%s:%s:%s", redisKey, entity, redChangeKey)
-	counterStr, err := rc.client.Get(entityKey).Result()
+	counterStr, err := client.Get(entityKey).Result()
 	if err != nil {
 		return 0, err
 	}
 	counter, err := strconv.Atoi(counterStr)
 	if err != nil {
-		log.Panic(err)
+		return 0, err
 	}
 	return counter, nil
 }
 
-func newRedisClient(config *config.ConfigData) *redisClient {
-	client := redis.NewClient(&redis.Options{
-		Addr:     config.Redis.Address,
-		Password: config.Redis.Password,
-		DB:       config.Redis.DataBase,
+// newRedisClient connects to Cluster or Sentinel if their addresses are set, or to a single node
+func newRedisClient(conf config.ConfigRedis) (*redisClient, error) {
+	tlsConfig, err := redisTLSConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var client redis.UniversalClient
+	switch {
+	case conf.ClusterAddresses != "":
+		client = redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        splitAddresses(conf.ClusterAddresses),
+			Password:     conf.Password,
+			PoolSize:     conf.PoolSize,
+			DialTimeout:  conf.DialTimeout,
+			ReadTimeout:  conf.ReadTimeout,
+			WriteTimeout: conf.WriteTimeout,
+			TLSConfig:    tlsConfig,
+		})
+	case conf.MasterName != "":
+		client = redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    conf.MasterName,
+			SentinelAddrs: splitAddresses(conf.SentinelAddresses),
+			Password:      conf.Password,
+			DB:            conf.DataBase,
+			PoolSize:      conf.PoolSize,
+			DialTimeout:   conf.DialTimeout,
+			ReadTimeout:   conf.ReadTimeout,
+			WriteTimeout:  conf.WriteTimeout,
+			TLSConfig:     tlsConfig,
+		})
+	default:
+		client = redis.NewClient(&redis.Options{
+			Addr:         conf.Address,
+			Password:     conf.Password,
+			DB:           conf.DataBase,
+			PoolSize:     conf.PoolSize,
+			DialTimeout:  conf.DialTimeout,
+			ReadTimeout:  conf.ReadTimeout,
+			WriteTimeout: conf.WriteTimeout,
+			TLSConfig:    tlsConfig,
+		})
+	}
+
+	rc := &redisClient{
+		client:  client,
+		timeout: conf.Timeout,
+	}
+	// cache isn't invalidated during outage, so everything cached before it is outdated
+	rc.breaker = newCircuitBreaker(conf.BreakerThreshold, conf.BreakerCooldown, func() {
+		for _, entity := range versionedEntities {
+			rc.updateChangeCounter(context.Background(), entity)
+		}
+	})
+
+	// every command result is counted by the breaker, pipelines included
+	client.WrapProcess(func(process func(redis.Cmder) error) func(redis.Cmder) error {
+		return func(cmd redis.Cmder) error {
+			err := process(cmd)
+			rc.breaker.record(err)
+			return err
+		}
+	})
+	client.WrapProcessPipeline(func(process func([]redis.Cmder) error) func([]redis.Cmder) error {
+		return func(cmds []redis.Cmder) error {
+			err := process(cmds)
+			rc.breaker.record(err)
+			return err
+		}
 	})
-	return &redisClient{client}
+	return rc, nil
+}
+
+// redisTLSConfig returns TLS config of redis connection, or nil if TLS is off
+func redisTLSConfig(conf config.ConfigRedis) (*tls.Config, error) {
+	if !conf.TLS {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: conf.TLSSkipVerify,
+	}
+	if conf.TLSCAFile != "" {
+		caData, err := os.ReadFile(conf.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("redis CA file: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("redis CA file %s: no certificates found", conf.TLSCAFile)
+		}
+	}
+	return tlsConfig, nil
+}
+
+// splitAddresses splits comma-separated address list
+func splitAddresses(addresses string) []string {
+	addressList := make([]string, 0)
+	for _, address := range strings.Split(addresses, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addressList = append(addressList, address)
+		}
+	}
+	return addressList
 }
 
 // RepoHandler is a repository handler
 type RepoHandler struct {
-	redis *redisClient
+	redis        *redisClient
+	queryTimeout time.Duration
 }
 
-// NewRepoHandler creates new repository handler
-func NewRepoHandler(config *config.ConfigData) *RepoHandler {
-	redis := newRedisClient(config)
+// NewRepoHandler creates new repository handler.
+// Redis isn't connected for storages running without external services, then cache is bypassed
+func NewRepoHandler(config *config.ConfigData) (*RepoHandler, error) {
+	redis := &redisClient{temp: newTempStore()}
+	if config.RedisEnabled() {
+		var err error
+		redis, err = newRedisClient(config.Redis)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return &RepoHandler{
-		redis: redis,
+		redis:        redis,
+		queryTimeout: config.Database.QueryTimeout,
+	}, nil
+}
+
+// FlushCache deletes cached entities and returns the number of deleted keys.
+// Captcha challenges aren't cache, so they are kept
+func (rh *RepoHandler) FlushCache(ctx context.Context) (int, error) {
+	client, cancel, err := rh.redis.withContext(ctx)
+	defer cancel()
+	if err != nil {
+		return 0, err
+	}
+
+	cluster, ok := client.(*redis.ClusterClient)
+	if !ok {
+		return flushNode(client)
+	}
+
+	// keys are spread over masters, and each of them is scanned separately
+	var (
+		mu      sync.Mutex
+		deleted int
+	)
+	err = cluster.ForEachMaster(func(master *redis.Client) error {
+		n, err := flushNode(master)
+		mu.Lock()
+		deleted += n
+		mu.Unlock()
+		return err
+	})
+	return deleted, err
+}
+
+// flushNode deletes cached entities of a single node.
+// Keys are deleted one by one, so they don't have to be in the same cluster slot
+func flushNode(client redis.Cmdable) (int, error) {
+	keepPrefixes := []string{
+		fmt.Sprintf("%s:%s:", redisKey, redCaptchaKey),
+		fmt.Sprintf("%s:%s:", redisKey, redCaptchaSolvedKey),
+	}
+
+	var (
+		cursor  uint64
+		deleted int
+	)
+	for {
+		keyList, nextCursor, err := client.Scan(cursor, redisKey+":*", 100).Result()
+		if err != nil {
+			return deleted, err
+		}
+
+		pipe := client.Pipeline()
+		delList := make([]*redis.IntCmd, 0, len(keyList))
+	keyLoop:
+		for _, key := range keyList {
+			for _, prefix := range keepPrefixes {
+				if strings.HasPrefix(key, prefix) {
+					continue keyLoop
+				}
+			}
+			delList = append(delList, pipe.Del(key))
+		}
+		if len(delList) > 0 {
+			_, err := pipe.Exec()
+			if err != nil {
+				return deleted, err
+			}
+			for _, del := range delList {
+				deleted += int(del.Val())
+			}
+		}
+		pipe.Close()
+
+		cursor = nextCursor
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+// Ping checks redis connection, it is always successful without redis
+func (rh *RepoHandler) Ping(ctx context.Context) error {
+	if rh.redis.client == nil {
+		return nil
+	}
+	client, cancel, err := rh.redis.withContext(ctx)
+	defer cancel()
+	if err != nil {
+		return err
+	}
+	return client.Ping().Err()
+}
+
+// Close closes redis client
+func (rh *RepoHandler) Close() error {
+	if rh.redis.client == nil {
+		return nil
+	}
+	return rh.redis.client.Close()
+}
+
+// dbContext limits db query time by configured timeout
+func (rh *RepoHandler) dbContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if rh.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, rh.queryTimeout)
 }
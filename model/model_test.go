@@ -0,0 +1,332 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+)
+
+// newTestRepo creates repository handler connected to in-process redis
+func newTestRepo(t *testing.T) (*RepoHandler, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return &RepoHandler{
+		redis: &redisClient{
+			client: client,
+			temp:   newTempStore(),
+		},
+	}, server
+}
+
+// putCache stores cached entity content with given version
+func putCache(t *testing.T, server *miniredis.Miniredis, entity, key string, content interface{}, version int) {
+	t.Helper()
+	contentJSON, err := json.Marshal(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	containerJSON, err := json.Marshal(RedisContainer{Version: version, Content: string(contentJSON)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Set(fmt.Sprintf("%s:%s:%s", redisKey, entity, key), string(containerJSON))
+}
+
+// setChangeCounter sets cache version of the entity
+func setChangeCounter(server *miniredis.Miniredis, entity string, version int) {
+	server.Set(fmt.Sprintf("%s:%s:%s", redisKey, entity, redChangeKey), strconv.Itoa(version))
+}
+
+// waitCache waits until cache is updated in background to the version, and returns its content
+func waitCache(t *testing.T, server *miniredis.Miniredis, entity, key string, version int) string {
+	t.Helper()
+	entityKey := fmt.Sprintf("%s:%s:%s", redisKey, entity, key)
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		containerJSON, err := server.Get(entityKey)
+		if err != nil {
+			continue
+		}
+		container := RedisContainer{}
+		json.Unmarshal([]byte(containerJSON), &container)
+		if container.Version == version {
+			return container.Content
+		}
+	}
+	t.Fatalf("cache %s isn't updated to version %d", entityKey, version)
+	return ""
+}
+
+func assertJSON(t *testing.T, want, got interface{}) {
+	t.Helper()
+	wantJSON, _ := json.Marshal(want)
+	gotJSON, _ := json.Marshal(got)
+	if string(wantJSON) != string(gotJSON) {
+		t.Errorf("want %s, got %s", wantJSON, gotJSON)
+	}
+}
+
+func TestCachedGetters(t *testing.T) {
+	cachedThread := fakeThread
+	cachedThread.Title = "cached thread"
+	cachedPost := fakePost
+	cachedPost.Text = "cached post"
+	cachedFilter := fakeFilter
+	cachedFilter.Pattern = "cached"
+
+	tests := []struct {
+		name   string
+		entity string
+		key    string
+		method string // DAC method called on cache miss
+		stored interface{}
+		cached interface{}
+		get    func(context.Context, *RepoHandler, *fakeDB) (interface{}, error)
+	}{
+		{
+			name:   "board list",
+			entity: redBoardList,
+			method: "GetBoardList",
+			stored: []Board{fakeBoard},
+			cached: []Board{{Key: "b", Name: "cached board"}},
+			get: func(ctx context.Context, repo *RepoHandler, dac *fakeDB) (interface{}, error) {
+				return NewBoardModel(repo, dac, nil).GetList(ctx), nil
+			},
+		},
+		{
+			name:   "board",
+			entity: redBoardKey,
+			key:    "b",
+			method: "GetBoard",
+			stored: fakeBoard,
+			cached: Board{Key: "b", Name: "cached board"},
+			get: func(ctx context.Context, repo *RepoHandler, dac *fakeDB) (interface{}, error) {
+				return NewBoardModel(repo, dac, nil).GetItem(ctx, "b")
+			},
+		},
+		{
+			name:   "threads by board",
+			entity: redThreadBoardKey,
+			key:    "b",
+			method: "GetTheadsByBoard",
+			stored: []Thread{fakeThread},
+			cached: []Thread{cachedThread},
+			get: func(ctx context.Context, repo *RepoHandler, dac *fakeDB) (interface{}, error) {
+				return NewThreadModel(repo, dac, nil).GetTheadsByBoard(ctx, "b")
+			},
+		},
+		{
+			name:   "threads by author",
+			entity: redThreadAuthorKey,
+			key:    "author",
+			method: "GetThreadsByAuthor",
+			stored: []Thread{fakeThread},
+			cached: []Thread{cachedThread},
+			get: func(ctx context.Context, repo *RepoHandler, dac *fakeDB) (interface{}, error) {
+				return NewThreadModel(repo, dac, nil).GetThreadsByAuthor(ctx, "author")
+			},
+		},
+		{
+			name:   "thread",
+			entity: redThreadKey,
+			key:    "1",
+			method: "GetThread",
+			stored: fakeThread,
+			cached: cachedThread,
+			get: func(ctx context.Context, repo *RepoHandler, dac *fakeDB) (interface{}, error) {
+				return NewThreadModel(repo, dac, nil).GetThread(ctx, 1)
+			},
+		},
+		{
+			name:   "posts by thread",
+			entity: redPostThreadKey,
+			key:    "1",
+			method: "GetPostsByThread",
+			stored: []Post{fakePost},
+			cached: []Post{cachedPost},
+			get: func(ctx context.Context, repo *RepoHandler, dac *fakeDB) (interface{}, error) {
+				return NewPostModel(repo, dac, nil).GetPostsByThread(ctx, 1)
+			},
+		},
+		{
+			name:   "posts by author",
+			entity: redPostAuthorKey,
+			key:    "author",
+			method: "GetPostsByAuthor",
+			stored: []Post{fakePost},
+			cached: []Post{cachedPost},
+			get: func(ctx context.Context, repo *RepoHandler, dac *fakeDB) (interface{}, error) {
+				return NewPostModel(repo, dac, nil).GetPostsByAuthor(ctx, "author")
+			},
+		},
+		{
+			name:   "post",
+			entity: redPostKey,
+			key:    "1",
+			method: "GetPost",
+			stored: fakePost,
+			cached: cachedPost,
+			get: func(ctx context.Context, repo *RepoHandler, dac *fakeDB) (interface{}, error) {
+				return NewPostModel(repo, dac, nil).GetPost(ctx, 1)
+			},
+		},
+		{
+			name:   "author",
+			entity: redAuthorKey,
+			key:    "author",
+			method: "GetAuthor",
+			stored: fakeAuthor,
+			cached: Author{Key: "cached"},
+			get: func(ctx context.Context, repo *RepoHandler, dac *fakeDB) (interface{}, error) {
+				return NewAuthorModel(repo, dac).GetAuthor(ctx, "author")
+			},
+		},
+		{
+			name:   "filters by board",
+			entity: redFilterBoardKey,
+			key:    "b",
+			method: "GetFiltersByBoard",
+			stored: []Filter{fakeFilter},
+			cached: []Filter{cachedFilter},
+			get: func(ctx context.Context, repo *RepoHandler, dac *fakeDB) (interface{}, error) {
+				return NewFilterModel(repo, dac, nil).GetFiltersByBoard(ctx, "b")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name+"/miss", func(t *testing.T) {
+			repo, server := newTestRepo(t)
+			dac := newFakeDB()
+
+			got, err := tt.get(context.Background(), repo, dac)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, tt.stored, got)
+			if n := dac.count(tt.method); n != 1 {
+				t.Fatalf("want 1 db call, got %d", n)
+			}
+
+			// the next call is served from the updated cache
+			assertJSON(t, tt.stored, json.RawMessage(waitCache(t, server, tt.entity, tt.key, 1)))
+			got, err = tt.get(context.Background(), repo, dac)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, tt.stored, got)
+			if n := dac.count(tt.method); n != 1 {
+				t.Errorf("want cache hit, got %d db calls", n)
+			}
+		})
+		t.Run(tt.name+"/hit", func(t *testing.T) {
+			repo, server := newTestRepo(t)
+			dac := newFakeDB()
+			setChangeCounter(server, tt.entity, 3)
+			putCache(t, server, tt.entity, tt.key, tt.cached, 3)
+
+			got, err := tt.get(context.Background(), repo, dac)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, tt.cached, got)
+			if n := dac.count(tt.method); n != 0 {
+				t.Errorf("want no db calls, got %d", n)
+			}
+		})
+		t.Run(tt.name+"/stale", func(t *testing.T) {
+			repo, server := newTestRepo(t)
+			dac := newFakeDB()
+			setChangeCounter(server, tt.entity, 4)
+			putCache(t, server, tt.entity, tt.key, tt.cached, 3)
+
+			got, err := tt.get(context.Background(), repo, dac)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, tt.stored, got)
+			if n := dac.count(tt.method); n != 1 {
+				t.Errorf("want 1 db call, got %d", n)
+			}
+			assertJSON(t, tt.stored, json.RawMessage(waitCache(t, server, tt.entity, tt.key, 5)))
+		})
+	}
+}
+
+func TestCachedGettersNotFound(t *testing.T) {
+	repo, _ := newTestRepo(t)
+	dac := newFakeDB()
+	ctx := context.Background()
+
+	if _, err := NewBoardModel(repo, dac, nil).GetItem(ctx, "none"); err != ErrNotFound {
+		t.Errorf("board: want ErrNotFound, got %v", err)
+	}
+	if _, err := NewThreadModel(repo, dac, nil).GetThread(ctx, 404); err != ErrNotFound {
+		t.Errorf("thread: want ErrNotFound, got %v", err)
+	}
+	if _, err := NewPostModel(repo, dac, nil).GetPost(ctx, 404); err != ErrNotFound {
+		t.Errorf("post: want ErrNotFound, got %v", err)
+	}
+	if _, err := NewAuthorModel(repo, dac).GetAuthor(ctx, "none"); err != ErrNotFound {
+		t.Errorf("author: want ErrNotFound, got %v", err)
+	}
+}
+
+func TestCachedGettersWithoutRedis(t *testing.T) {
+	repo := &RepoHandler{redis: &redisClient{temp: newTempStore()}}
+	dac := newFakeDB()
+	model := NewThreadModel(repo, dac, nil)
+
+	for i := 0; i < 2; i++ {
+		got, err := model.GetThread(context.Background(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertJSON(t, fakeThread, got)
+	}
+	if n := dac.count("GetThread"); n != 2 {
+		t.Errorf("want every call read from db, got %d db calls", n)
+	}
+}
+
+func TestVersionedEntitiesUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, entity := range versionedEntities {
+		if seen[entity] {
+			t.Errorf("cache entity %q is used by several getters", entity)
+		}
+		seen[entity] = true
+	}
+}
+
+func TestPostCacheKeys(t *testing.T) {
+	repo, server := newTestRepo(t)
+	dac := newFakeDB()
+	model := NewPostModel(repo, dac, nil)
+	ctx := context.Background()
+
+	// posts of thread 1 must not be returned as posts of author "1"
+	_, err := model.GetPostsByThread(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitCache(t, server, redPostThreadKey, "1", 1)
+
+	_, err = model.GetPostsByAuthor(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := dac.count("GetPostsByAuthor"); n != 1 {
+		t.Errorf("posts by author are read from posts by thread cache")
+	}
+	waitCache(t, server, redPostAuthorKey, "1", 1)
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	// ReportKey represents unique report model key
+	ReportKey int
+	// ReportStatus represents report moderation status
+	ReportStatus string
+)
+
+// Report statuses
+const (
+	// ReportOpen is a report waiting in moderation queue
+	ReportOpen ReportStatus = "open"
+	// ReportDismissed is a report rejected by moderator
+	ReportDismissed ReportStatus = "dismissed"
+	// ReportResolved is a report the moderator acted on
+	ReportResolved ReportStatus = "resolved"
+)
+
+// ReportModelDB is a report model DB interaction interface
+type ReportModelDB interface {
+	GetOpenReports(context.Context) ([]*Report, error)
+	GetReport(context.Context, ReportKey) (*Report, error)
+	PutReport(context.Context, Report) (ReportKey, error)
+	SetReportStatus(context.Context, ReportKey, ReportStatus) error
+}
+
+// Report model
+
+// Report is a db structure of report table.
+// There is only one open report per post, repeated reports increase its count
+type Report struct {
+	Key              ReportKey
+	Post             PostKey
+	Reason           string
+	Count            int
+	Status           ReportStatus
+	CreationDateTime time.Time
+	UpdateDateTime   time.Time
+}
+
+// ReportModel is a report model
+type ReportModel struct {
+	repoConnection *RepoHandler
+	modelDAC       ReportModelDB
+	modLog         *ModActionModel
+}
+
+// NewReportModel creates new ReportModel
+func NewReportModel(repoConnection *RepoHandler, modelDAC ReportModelDB, modLog *ModActionModel) *ReportModel {
+	return &ReportModel{
+		repoConnection: repoConnection,
+		modelDAC:       modelDAC,
+		modLog:         modLog,
+	}
+}
+
+// GetQueue returns open reports, most reported first
+func (m *ReportModel) GetQueue(ctx context.Context) ([]*Report, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.GetOpenReports(ctx)
+}
+
+// GetReport returns certain report by key
+func (m *ReportModel) GetReport(ctx context.Context, reportID ReportKey) (*Report, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	return m.modelDAC.GetReport(ctx, reportID)
+}
+
+// PutReport reports a post, or increases open report count if it was already reported
+func (m *ReportModel) PutReport(ctx context.Context, postID PostKey, reason string) (ReportKey, error) {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	now := time.Now()
+	return m.modelDAC.PutReport(ctx, Report{
+		Post:             postID,
+		Reason:           reason,
+		Count:            1,
+		Status:           ReportOpen,
+		CreationDateTime: now,
+		UpdateDateTime:   now,
+	})
+}
+
+// Dismiss removes report from moderation queue without action
+func (m *ReportModel) Dismiss(ctx context.Context, reportID ReportKey, mod ModInfo) error {
+	return m.setStatus(ctx, reportID, ReportDismissed, ModReportDismiss, mod)
+}
+
+// Resolve removes report from moderation queue after moderator action
+func (m *ReportModel) Resolve(ctx context.Context, reportID ReportKey, mod ModInfo) error {
+	return m.setStatus(ctx, reportID, ReportResolved, ModReportResolve, mod)
+}
+
+// Reopen returns report to moderation queue, if moderator action on it failed
+func (m *ReportModel) Reopen(ctx context.Context, reportID ReportKey, mod ModInfo) error {
+	return m.setStatus(ctx, reportID, ReportOpen, ModReportReopen, mod)
+}
+
+func (m *ReportModel) setStatus(ctx context.Context, reportID ReportKey, status ReportStatus, actionType ModActionType, mod ModInfo) error {
+	ctx, cancel := m.repoConnection.dbContext(ctx)
+	defer cancel()
+
+	reportItem, err := m.modelDAC.GetReport(ctx, reportID)
+	if err != nil {
+		return err
+	}
+
+	err = m.modelDAC.SetReportStatus(ctx, reportID, status)
+	if err != nil {
+		return err
+	}
+
+	m.modLog.log(ctx, ModAction{
+		Action: actionType,
+		Post:   &reportItem.Post,
+	}, mod)
+
+	return nil
+}
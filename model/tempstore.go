@@ -0,0 +1,71 @@
+package model
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// tempStore keeps temporary values like captcha challenges in process memory,
+// when redis isn't used. Missing and expired values are reported as redis.Nil
+type tempStore struct {
+	mu     sync.Mutex
+	values map[string]tempValue
+}
+
+type tempValue struct {
+	value   string
+	expires time.Time // zero for values without TTL
+}
+
+func newTempStore() *tempStore {
+	return &tempStore{values: make(map[string]tempValue)}
+}
+
+func (ts *tempStore) set(key, value string, ttl time.Duration) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	// expired values are dropped on write, there are few of them
+	now := time.Now()
+	for valueKey, valueItem := range ts.values {
+		if valueItem.expired(now) {
+			delete(ts.values, valueKey)
+		}
+	}
+
+	valueItem := tempValue{value: value}
+	if ttl > 0 {
+		valueItem.expires = now.Add(ttl)
+	}
+	ts.values[key] = valueItem
+}
+
+func (ts *tempStore) get(key string) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	valueItem, ok := ts.values[key]
+	if !ok || valueItem.expired(time.Now()) {
+		return "", redis.Nil
+	}
+	return valueItem.value, nil
+}
+
+// take reads and deletes the value
+func (ts *tempStore) take(key string) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	valueItem, ok := ts.values[key]
+	delete(ts.values, key)
+	if !ok || valueItem.expired(time.Now()) {
+		return "", redis.Nil
+	}
+	return valueItem.value, nil
+}
+
+func (tv tempValue) expired(now time.Time) bool {
+	return !tv.expires.IsZero() && now.After(tv.expires)
+}
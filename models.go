@@ -1,58 +1,109 @@
 package gochan
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
+	"log/slog"
 	"sync"
 
 	"github.com/ilyakaznacheev/gochan/config"
 	"github.com/ilyakaznacheev/gochan/db"
 	"github.com/ilyakaznacheev/gochan/model"
-
-	_ "github.com/lib/pq"
 )
 
 type modelContext struct {
+	storage        *db.Storage
 	repoConnection *model.RepoHandler
 	boardModel     *model.BoardModel
 	threadModel    *model.ThreadModel
 	postModel      *model.PostModel
 	authorModel    *model.AuthorModel
 	imageModel     *model.ImageModel
+	banModel       *model.BanModel
+	captchaModel   *model.CaptchaModel
+	filterModel    *model.FilterModel
+	reportModel    *model.ReportModel
+	modActionModel *model.ModActionModel
 }
 
-var mctx *modelContext
-var contextSingleton sync.Once
+var (
+	mctx             *modelContext
+	mctxErr          error
+	contextSingleton sync.Once
+)
 
-func getmodelContext(config *config.ConfigData) *modelContext {
+// getmodelContext connects to the database and creates models once
+func getmodelContext(config *config.ConfigData) (*modelContext, error) {
 	contextSingleton.Do(func() {
-		repoHnd := model.NewRepoHandler(config)
-
-		// pg, err := model.NewPGClient(config)
-		// if err != nil {
-		// 	log.Fatal(err)
-		// }
-
-		connStr := fmt.Sprintf(
-			"user=%s dbname=%s password=%s host=%s sslmode=%s",
-			config.Database.User,
-			config.Database.Name,
-			config.Database.Pass,
-			config.Database.Address,
-			config.Database.SSL,
-		)
-
-		dbConn, _ := sql.Open("postgres", connStr)
-
-		mctx = &modelContext{
-			repoConnection: repoHnd,
-			boardModel:     model.NewBoardModel(repoHnd, db.NewBoardDAC(dbConn)),
-			threadModel:    model.NewThreadModel(repoHnd, db.NewThreadDAC(dbConn)),
-			postModel:      model.NewPostModel(repoHnd, db.NewPostDAC(dbConn)),
-			authorModel:    model.NewAuthorModel(repoHnd, db.NewAuthorDAC(dbConn)),
-			imageModel:     model.NewImageModel(repoHnd, db.NewImageDAC(dbConn)),
+		repoHnd, err := model.NewRepoHandler(config)
+		if err != nil {
+			mctxErr = fmt.Errorf("redis: %v", err)
+			return
+		}
+
+		storage, err := db.OpenStorage(context.Background(), *config)
+		if err != nil {
+			repoHnd.Close()
+			mctxErr = fmt.Errorf("database: %v", err)
+			return
 		}
+
+		mctx = newModelContext(config, repoHnd, storage)
 	})
 
-	return mctx
+	return mctx, mctxErr
+}
+
+// newModelContext creates models on top of the opened storage and cache
+func newModelContext(config *config.ConfigData, repoHnd *model.RepoHandler, storage *db.Storage) *modelContext {
+	modActionModel := model.NewModActionModel(repoHnd, storage.ModAction)
+
+	return &modelContext{
+		storage:        storage,
+		repoConnection: repoHnd,
+		boardModel:     model.NewBoardModel(repoHnd, storage.Board, modActionModel),
+		threadModel:    model.NewThreadModel(repoHnd, storage.Thread, modActionModel),
+		postModel:      model.NewPostModel(repoHnd, storage.Post, modActionModel),
+		authorModel:    model.NewAuthorModel(repoHnd, storage.Author),
+		imageModel:     model.NewImageModel(repoHnd, storage.Image),
+		banModel:       model.NewBanModel(repoHnd, storage.Ban, modActionModel),
+		captchaModel:   model.NewCaptchaModel(repoHnd, config.Captcha),
+		filterModel:    model.NewFilterModel(repoHnd, storage.Filter, modActionModel),
+		reportModel:    model.NewReportModel(repoHnd, storage.Report, modActionModel),
+		modActionModel: modActionModel,
+	}
+}
+
+// close closes database pool and then redis client,
+// so cache updates of the last queries still have a connection
+func (m *modelContext) close() error {
+	dbErr := m.storage.Close()
+	redisErr := m.repoConnection.Close()
+	if dbErr != nil {
+		return fmt.Errorf("close database: %v", dbErr)
+	}
+	if redisErr != nil {
+		return fmt.Errorf("close redis: %v", redisErr)
+	}
+	return nil
+}
+
+// logFilterMatches saves filter matches of the new post
+// and sends it to moderation queue if any filter asks for it.
+// The post is already saved, so request cancellation doesn't stop it
+func (mctx *modelContext) logFilterMatches(ctx context.Context, result *model.FilterResult, threadID model.ThreadKey, postID model.PostKey) {
+	ctx = context.WithoutCancel(ctx)
+	mctx.filterModel.LogMatches(ctx, result, &threadID, &postID)
+	if !result.Moderate {
+		return
+	}
+	for _, matchItem := range result.Matches {
+		if matchItem.Action != model.FilterModerate {
+			continue
+		}
+		_, err := mctx.reportModel.PutReport(ctx, postID, fmt.Sprintf("filter #%d: %s", matchItem.Filter, matchItem.Text))
+		if err != nil {
+			slog.ErrorContext(ctx, "can't report filtered post", "post", postID, "err", err)
+		}
+	}
 }
@@ -2,17 +2,53 @@ package gochan
 
 import (
 	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/ilyakaznacheev/gochan/model"
 )
 
+type contextKey string
+
+const (
+	ctxClientIP contextKey = "client-ip"
+	ctxAuthorID contextKey = "author-id"
+)
+
+// withPosterInfo puts poster IP and author ID into request context for resolvers
+func withPosterInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorID := readAuthorID(r)
+		if authorID == "" {
+			authorID = model.AuthorKey(uuid.New().String())
+			http.SetCookie(w, &http.Cookie{
+				Name:    "author_id",
+				Value:   string(authorID),
+				Expires: time.Now().Add(1 * time.Minute),
+			})
+		}
+
+		ctx := context.WithValue(r.Context(), ctxClientIP, getClientIP(r))
+		ctx = context.WithValue(ctx, ctxAuthorID, authorID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func posterInfo(ctx context.Context) (string, model.AuthorKey) {
+	clientIP, _ := ctx.Value(ctxClientIP).(string)
+	authorID, _ := ctx.Value(ctxAuthorID).(model.AuthorKey)
+	return clientIP, authorID
+}
+
 // Resolver resolvers GraphQL requests
 type Resolver struct {
-	rh *model.RepoHandler
+	model *modelContext
 }
 
-func newResolver(rh *model.RepoHandler) *Resolver {
-	return &Resolver{rh}
+func newResolver(model *modelContext) *Resolver {
+	return &Resolver{model}
 }
 
 // GetHome resolves getHome query
@@ -22,17 +58,29 @@ func (r *Resolver) GetHome(ctx context.Context) (*HomeReprGQL, error) {
 
 // GetBoard resolves getBoard query
 func (r *Resolver) GetBoard(ctx context.Context, args struct{ ID string }) (*BoardReprGQL, error) {
-	return &BoardReprGQL{}, nil
+	boardData, err := r.model.boardModel.GetItem(ctx, model.BoardKey(args.ID))
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+	return &BoardReprGQL{boardData}, nil
 }
 
 // GetThread resolves getThread query
 func (r *Resolver) GetThread(ctx context.Context, args struct{ ID int32 }) (*ThreadReprGQL, error) {
-	return &ThreadReprGQL{}, nil
+	threadData, err := r.model.threadModel.GetThread(ctx, model.ThreadKey(args.ID))
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+	return &ThreadReprGQL{threadData}, nil
 }
 
 // GetPost resolves getPost query
 func (r *Resolver) GetPost(ctx context.Context, args struct{ ID int32 }) (*PostReprGQL, error) {
-	return &PostReprGQL{}, nil
+	postData, err := r.model.postModel.GetPost(ctx, model.PostKey(args.ID))
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+	return &PostReprGQL{postData}, nil
 }
 
 // GetAuthor resolves getAuthor query
@@ -40,6 +88,51 @@ func (r *Resolver) GetAuthor(ctx context.Context, args struct{ ID string }) (*Au
 	return &AuthorReprGQL{}, nil
 }
 
+// GetCaptcha resolves getCaptcha query
+func (r *Resolver) GetCaptcha(ctx context.Context, args struct{ BoardID string }) (*CaptchaReprGQL, error) {
+	_, authorID := posterInfo(ctx)
+
+	boardData, err := r.model.boardModel.GetItem(ctx, model.BoardKey(args.BoardID))
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+	if !r.model.captchaModel.IsRequired(ctx, boardData, authorID) {
+		return nil, nil
+	}
+
+	captchaID, err := r.model.captchaModel.NewChallenge(ctx)
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+	return &CaptchaReprGQL{captchaID}, nil
+}
+
+// checkPoster checks poster bans and captcha on the board
+func (r *Resolver) checkPoster(ctx context.Context, boardName model.BoardKey, captchaInput *CaptchaInputGQL) error {
+	clientIP, authorID := posterInfo(ctx)
+
+	banData, err := r.model.banModel.CheckBan(ctx, clientIP, authorID, boardName)
+	if err != nil {
+		return err
+	}
+	if banData != nil {
+		return &model.BanError{Ban: banData}
+	}
+
+	boardData, err := r.model.boardModel.GetItem(ctx, boardName)
+	if err != nil {
+		return err
+	}
+	if !r.model.captchaModel.IsRequired(ctx, boardData, authorID) {
+		return nil
+	}
+	if captchaInput == nil ||
+		!r.model.captchaModel.Verify(ctx, model.CaptchaKey(captchaInput.ID), captchaInput.Answer, authorID) {
+		return model.ErrCaptchaFailed
+	}
+	return nil
+}
+
 func (r *Resolver) addPost(ctx context.Context, args struct {
 	BoardID string
 	Post    PostInputGQL
@@ -53,18 +146,110 @@ func (r *Resolver) addPost(ctx context.Context, args struct {
 func (r *Resolver) AddPost(ctx context.Context, args struct {
 	ThreadID int32
 	Post     PostInputGQL
+	Captcha  *CaptchaInputGQL
 }) (
 	*PostReprGQL, error,
 ) {
-	return &PostReprGQL{}, nil
+	_, authorID := posterInfo(ctx)
+
+	threadData, err := r.model.threadModel.GetThread(ctx, model.ThreadKey(args.ThreadID))
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+	if threadData.Locked {
+		return nil, newResolverError(model.ErrLocked)
+	}
+
+	err = r.checkPoster(ctx, threadData.BoardName, args.Captcha)
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+
+	inputText := args.Post.Text
+	filterResult, err := r.model.filterModel.Apply(ctx, threadData.BoardName, authorID, &inputText)
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+
+	newPost := model.Post{
+		Author:           authorID,
+		Thread:           threadData.Key,
+		CreationDateTime: time.Now(),
+		Text:             inputText,
+	}
+	newPost.Key, err = r.model.postModel.PutPost(ctx, newPost)
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+	r.model.logFilterMatches(ctx, filterResult, newPost.Thread, newPost.Key)
+	return &PostReprGQL{&newPost}, nil
 }
 
 // AddThread resolves addThread mutation
 func (r *Resolver) AddThread(ctx context.Context, args struct {
 	BoardID string
 	Thread  ThreadInputGQL
+	Captcha *CaptchaInputGQL
 }) (
 	*ThreadReprGQL, error,
 ) {
-	return &ThreadReprGQL{}, nil
+	_, authorID := posterInfo(ctx)
+	boardName := model.BoardKey(args.BoardID)
+
+	err := r.checkPoster(ctx, boardName, args.Captcha)
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+
+	inputTitle := args.Thread.Title
+	inputText := args.Thread.Post.Text
+	filterResult, err := r.model.filterModel.Apply(ctx, boardName, authorID, &inputTitle, &inputText)
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+
+	creationTime := time.Now()
+	newThread := model.Thread{
+		Title:            inputTitle,
+		AuthorID:         authorID,
+		BoardName:        boardName,
+		CreationDateTime: creationTime,
+	}
+	newPost := model.Post{
+		Author:           authorID,
+		CreationDateTime: creationTime,
+		Text:             inputText,
+	}
+
+	var postID model.PostKey
+	newThread.Key, postID, err = r.model.threadModel.CreateThreadWithOP(ctx, newThread, newPost, nil)
+	if err != nil {
+		return nil, newResolverError(err)
+	}
+	r.model.logFilterMatches(ctx, filterResult, newThread.Key, postID)
+	return &ThreadReprGQL{&newThread}, nil
+}
+
+// ReportPost resolves reportPost mutation
+func (r *Resolver) ReportPost(ctx context.Context, args struct {
+	PostID int32
+	Reason *string
+}) (
+	bool, error,
+) {
+	postData, err := r.model.postModel.GetPost(ctx, model.PostKey(args.PostID))
+	if err != nil {
+		return false, newResolverError(err)
+	}
+
+	var reason string
+	if args.Reason != nil {
+		reason = *args.Reason
+	}
+
+	_, err = r.model.reportModel.PutReport(ctx, postData.Key, reason)
+	if err != nil {
+		return false, newResolverError(err)
+	}
+	return true, nil
 }
@@ -2,15 +2,19 @@ package gochan
 
 import (
 	"context"
+	"io/fs"
 
 	"github.com/graph-gophers/graphql-go"
 	"github.com/ilyakaznacheev/gochan/model"
 )
 
-func getSchema(filename string, rh *model.RepoHandler) (*graphql.Schema, error) {
-	schemaRaw := GetRootSchema()
+func getSchema(assets fs.FS, model *modelContext) (*graphql.Schema, error) {
+	schemaRaw, err := readSchema(assets)
+	if err != nil {
+		return nil, err
+	}
 
-	return graphql.MustParseSchema(schemaRaw, newResolver(rh)), nil
+	return graphql.ParseSchema(schemaRaw, newResolver(model), graphql.Tracer(gqlTracer{}))
 }
 
 // Resolver types
@@ -26,17 +30,24 @@ func (r *HomeReprGQL) BOARDS(ctx context.Context) *[]*BoardReprGQL {
 
 // BoardReprGQL is GQL Board representation structure
 type BoardReprGQL struct {
+	board *model.Board
 }
 
 // ID resolves id field of schema type
 func (r *BoardReprGQL) ID(ctx context.Context) *string {
 	res := ""
+	if r.board != nil {
+		res = string(r.board.Key)
+	}
 	return &res
 }
 
 // TITLE resolves title field of schema type
 func (r *BoardReprGQL) TITLE(ctx context.Context) *string {
 	res := ""
+	if r.board != nil {
+		res = r.board.Name
+	}
 	return &res
 }
 
@@ -47,16 +58,24 @@ func (r *BoardReprGQL) THREADS(ctx context.Context) *[]*ThreadReprGQL {
 
 // ThreadReprGQL is GQL Thread representation structure
 type ThreadReprGQL struct {
+	thread *model.Thread
 }
 
 // ID resolves id field of schema type
 func (r *ThreadReprGQL) ID(ctx context.Context) *graphql.ID {
 	res := graphql.ID("")
+	if r.thread != nil {
+		res = graphql.ID(r.thread.Key.String())
+	}
 	return &res
 }
 
+// TITLE resolves title field of schema type
 func (r *ThreadReprGQL) TITLE(ctx context.Context) *string {
 	res := ""
+	if r.thread != nil {
+		res = r.thread.Title
+	}
 	return &res
 }
 
@@ -77,17 +96,24 @@ func (r *ThreadReprGQL) AUTHOR(ctx context.Context) *AuthorReprGQL {
 
 // PostReprGQL is GQL Post representation structure
 type PostReprGQL struct {
+	post *model.Post
 }
 
 // ID resolves id field of schema type
 func (r *PostReprGQL) ID(ctx context.Context) *graphql.ID {
 	res := graphql.ID("")
+	if r.post != nil {
+		res = graphql.ID(r.post.Key.String())
+	}
 	return &res
 }
 
-// TECT resolves text field of schema type
+// TEXT resolves text field of schema type
 func (r *PostReprGQL) TEXT(ctx context.Context) *string {
 	res := ""
+	if r.post != nil {
+		res = r.post.Text
+	}
 	return &res
 }
 
@@ -127,6 +153,23 @@ func (r *ImageReprGQL) URL(ctx context.Context) *string {
 	return &res
 }
 
+// CaptchaReprGQL is GQL Captcha representation structure
+type CaptchaReprGQL struct {
+	key model.CaptchaKey
+}
+
+// ID resolves id field of schema type
+func (r *CaptchaReprGQL) ID(ctx context.Context) *string {
+	res := string(r.key)
+	return &res
+}
+
+// URL resolves url field of schema type
+func (r *CaptchaReprGQL) URL(ctx context.Context) *string {
+	res := "/captcha/" + string(r.key) + ".png"
+	return &res
+}
+
 // PostInputGQL is GQL Post input structure
 type PostInputGQL struct {
 	Text string
@@ -137,3 +180,9 @@ type ThreadInputGQL struct {
 	Title string
 	Post  PostInputGQL
 }
+
+// CaptchaInputGQL is GQL Captcha input structure
+type CaptchaInputGQL struct {
+	ID     string
+	Answer string
+}
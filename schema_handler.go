@@ -1,16 +1,28 @@
 package gochan
 
-//go:generate go-bindata -pkg $GOPACKAGE -o schema_types.go schema/
-
 import (
 	"bytes"
+	"fmt"
+	"io/fs"
+	"path"
 )
 
-// GetRootSchema returnes schema from generated by go-bindata source file
-func GetRootSchema() string {
+// readSchema joins all schema files of the file system
+func readSchema(fsys fs.FS) (string, error) {
+	fileList, err := fs.Glob(fsys, path.Join(schemaDir, "*.graphql"))
+	if err != nil {
+		return "", err
+	}
+	if len(fileList) == 0 {
+		return "", fmt.Errorf("no schema files in %s", schemaDir)
+	}
+
 	buf := bytes.Buffer{}
-	for _, name := range AssetNames() {
-		b := MustAsset(name)
+	for _, name := range fileList {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return "", err
+		}
 		buf.Write(b)
 
 		// Add a newline if the file does not end in a newline.
@@ -19,5 +31,5 @@ func GetRootSchema() string {
 		}
 	}
 
-	return buf.String()
+	return buf.String(), nil
 }
@@ -0,0 +1,193 @@
+package gochan
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+func TestGraphQLQueries(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		author model.AuthorKey
+		setup  func(*testing.T, *testServer)
+		data   string // expected data, skipped if empty
+		code   string // expected error code, no errors if empty
+	}{
+		{
+			name:  "home",
+			query: `{ getHome { boards { id } } }`,
+			data:  `{"getHome":{"boards":[]}}`,
+		},
+		{
+			name:  "board",
+			query: `{ getBoard(id: "b") { id title } }`,
+			data:  `{"getBoard":{"id":"b","title":"Test board"}}`,
+		},
+		{
+			name:  "unknown board",
+			query: `{ getBoard(id: "none") { id } }`,
+			data:  `{"getBoard":null}`,
+			code:  "NOT_FOUND",
+		},
+		{
+			name:  "thread",
+			query: `{ getThread(id: {thread}) { id title } }`,
+			data:  `{"getThread":{"id":"{thread}","title":"Test thread"}}`,
+		},
+		{
+			name:  "unknown thread",
+			query: `{ getThread(id: 404) { id } }`,
+			data:  `{"getThread":null}`,
+			code:  "NOT_FOUND",
+		},
+		{
+			name:  "post",
+			query: `{ getPost(id: {post}) { id text } }`,
+			data:  `{"getPost":{"id":"{post}","text":"Test post"}}`,
+		},
+		{
+			name:  "author",
+			query: `{ getAuthor(id: "op") { posts { id } } }`,
+			data:  `{"getAuthor":{"posts":[]}}`,
+		},
+		{
+			name:  "captcha not required",
+			query: `{ getCaptcha(boardID: "b") { id } }`,
+			data:  `{"getCaptcha":null}`,
+		},
+		{
+			name:   "add thread",
+			query:  `mutation { addThread(boardID: "b", thread: {title: "New thread", post: {text: "New post"}}) { title } }`,
+			author: "poster",
+			data:   `{"addThread":{"title":"New thread"}}`,
+		},
+		{
+			name:   "add thread banned",
+			query:  `mutation { addThread(boardID: "b", thread: {title: "New thread", post: {text: "New post"}}) { title } }`,
+			author: testBannedAuthor,
+			data:   `{"addThread":null}`,
+			code:   "BANNED",
+		},
+		{
+			name:   "add thread filtered",
+			query:  `mutation { addThread(boardID: "b", thread: {title: "New thread", post: {text: "spam"}}) { title } }`,
+			author: "poster",
+			data:   `{"addThread":null}`,
+			code:   "BAD_INPUT",
+		},
+		{
+			name:   "add thread without captcha",
+			query:  `mutation { addThread(boardID: "b", thread: {title: "New thread", post: {text: "New post"}}) { title } }`,
+			author: "poster",
+			setup:  enableCaptcha,
+			data:   `{"addThread":null}`,
+			code:   "BAD_INPUT",
+		},
+		{
+			name:   "add post",
+			query:  `mutation { addPost(threadID: {thread}, post: {text: "Reply"}) { text } }`,
+			author: "poster",
+			data:   `{"addPost":{"text":"Reply"}}`,
+		},
+		{
+			name:   "add post locked",
+			query:  `mutation { addPost(threadID: {thread}, post: {text: "Reply"}) { text } }`,
+			author: "poster",
+			setup: func(t *testing.T, s *testServer) {
+				err := s.model.threadModel.SetLocked(context.Background(), s.thread, true, model.ModInfo{Moderator: "test"})
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			data: `{"addPost":null}`,
+			code: "LOCKED",
+		},
+		{
+			name:  "report post",
+			query: `mutation { reportPost(postID: {post}, reason: "offtopic") }`,
+			data:  `{"reportPost":true}`,
+		},
+		{
+			name:  "report unknown post",
+			query: `mutation { reportPost(postID: 404) }`,
+			code:  "NOT_FOUND",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			schema, err := getSchema(getAssets(""), s.model)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.setup != nil {
+				tt.setup(t, s)
+			}
+
+			ctx := context.WithValue(context.Background(), ctxAuthorID, tt.author)
+			response := schema.Exec(ctx, s.expand(tt.query), "", nil)
+
+			if tt.code == "" && len(response.Errors) > 0 {
+				t.Fatalf("unexpected errors: %v", response.Errors)
+			}
+			if tt.code != "" {
+				if len(response.Errors) != 1 {
+					t.Fatalf("want one %s error, got %v", tt.code, response.Errors)
+				}
+				if code := response.Errors[0].Extensions["code"]; code != tt.code {
+					t.Errorf("want error code %s, got %v", tt.code, code)
+				}
+			}
+			if tt.data != "" && string(response.Data) != s.expand(tt.data) {
+				t.Errorf("want data %s, got %s", s.expand(tt.data), response.Data)
+			}
+		})
+	}
+}
+
+func TestGraphQLCaptchaURL(t *testing.T) {
+	s := newTestServer(t)
+	enableCaptcha(t, s)
+
+	w := s.serve(s.newJSONRequest("/api", `{"query": "{ getCaptcha(boardID: \"b\") { id URL } }"}`))
+
+	var response struct {
+		Data struct {
+			GetCaptcha struct {
+				ID  string
+				URL string
+			}
+		}
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("%v: %s", err, w.Body.String())
+	}
+	captchaData := response.Data.GetCaptcha
+	if captchaData.URL != "/captcha/"+captchaData.ID+".png" {
+		t.Errorf("wrong captcha URL %q", captchaData.URL)
+	}
+
+	// the challenge image is served by the URL
+	w = s.serve(s.newRequest("GET", captchaData.URL, nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "image/png") {
+		t.Errorf("captcha image isn't served: status %d", w.Code)
+	}
+}
+
+// enableCaptcha makes the test board require captcha
+func enableCaptcha(t *testing.T, s *testServer) {
+	t.Helper()
+	err := s.model.boardModel.UpdateBoard(context.Background(), model.Board{Key: "b", Name: "Test board", Captcha: true}, model.ModInfo{Moderator: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,18 +1,31 @@
 package gochan
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/graph-gophers/graphql-go/relay"
 
 	"github.com/ilyakaznacheev/gochan/config"
+	"github.com/ilyakaznacheev/gochan/db"
+	"github.com/ilyakaznacheev/gochan/metrics"
+	"github.com/ilyakaznacheev/gochan/tracing"
 )
 
+// tracingFlushTimeout limits sending of pending spans on exit
+const tracingFlushTimeout = 5 * time.Second
+
 // Server is a gochan server
 type Server struct {
 	conf config.ConfigData
@@ -30,35 +43,218 @@ func NewServer(conf *config.ConfigData) *Server {
 	return &Server{newConf}
 }
 
-// gracefulShutdown handle program interrupt by user
-func (s *Server) gracefulShutdown(action func()) {
-	var signalChan = make(chan os.Signal)
-	signal.Notify(signalChan, syscall.SIGTERM)
-	signal.Notify(signalChan, syscall.SIGINT)
+// Migrate runs database migration command: up, down or status
+func (s *Server) Migrate(command string) error {
+	storage, err := db.OpenStorage(context.Background(), s.conf)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	migrator, err := storage.Migrator()
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		appliedList, err := migrator.Up()
+		for _, migrationItem := range appliedList {
+			slog.Info("applied migration", "version", migrationItem.Version, "name", migrationItem.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(appliedList) == 0 {
+			slog.Info("database is up to date")
+		}
+	case "down":
+		migrationItem, err := migrator.Down()
+		if err != nil {
+			return err
+		}
+		if migrationItem == nil {
+			slog.Info("no migrations to revert")
+		} else {
+			slog.Info("reverted migration", "version", migrationItem.Version, "name", migrationItem.Name)
+		}
+	case "status":
+		statusList, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, statusItem := range statusList {
+			state := "pending"
+			if statusItem.Applied {
+				state = "applied " + statusItem.AppliedAt.Format(timeFormat)
+			}
+			fmt.Printf("%04d %-20s %s\n", statusItem.Version, statusItem.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", command)
+	}
+	return nil
+}
+
+// migrateUp applies pending migrations, memory storage has nothing to migrate
+func migrateUp(storage *db.Storage) error {
+	migrator, err := storage.Migrator()
+	if errors.Is(err, db.ErrNoSchema) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	appliedList, err := migrator.Up()
+	if err != nil {
+		return err
+	}
+	for _, migrationItem := range appliedList {
+		slog.Info("applied migration", "version", migrationItem.Version, "name", migrationItem.Name)
+	}
+	return nil
+}
+
+// Run starts server and blocks until it is stopped by SIGINT or SIGTERM.
+// In-flight requests are drained before database and redis connections are closed
+func (s *Server) Run() error {
+	err := os.MkdirAll(imgPath, 0755)
+	if err != nil {
+		return err
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), s.conf.Tracing)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("can't flush traces", "err", err)
+		}
+	}()
+
+	modelCtx, err := getmodelContext(&s.conf)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := modelCtx.close(); err != nil {
+			slog.Error("can't close connections", "err", err)
+		}
+		slog.Info("server stopped")
+	}()
+
+	err = migrateUp(modelCtx.storage)
+	if err != nil {
+		return err
+	}
+
+	handler, err := newRouter(s.conf, modelCtx)
+	if err != nil {
+		return err
+	}
+
+	httpServer := s.newHTTPServer(handler)
+	listener, err := listen(s.conf.HTTP.Address)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
+	serverErr := make(chan error, 1)
 	go func() {
-		<-signalChan
-		action()
-		log.Println("stopping server")
-		os.Exit(0)
+		slog.Info("starting server", "address", listener.Addr().String())
+		if s.conf.HTTP.TLSCert != "" {
+			serverErr <- httpServer.ServeTLS(listener, s.conf.HTTP.TLSCert, s.conf.HTTP.TLSKey)
+		} else {
+			serverErr <- httpServer.Serve(listener)
+		}
 	}()
+
+	select {
+	case err = <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("stopping server")
+	shutdownCtx, cancel := context.WithCancel(context.Background())
+	if s.conf.HTTP.ShutdownTimeout > 0 {
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, s.conf.HTTP.ShutdownTimeout)
+	}
+	defer cancel()
+
+	err = httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		// drain timeout is over, drop the rest of connections
+		httpServer.Close()
+		return fmt.Errorf("shutdown: %v", err)
+	}
+	return nil
 }
 
-// Run starts server
-func (s *Server) Run() {
-	modelCtx := getmodelContext(&s.conf)
-	requestHandler := newRequestHandler(modelCtx)
+// newRouter creates handler of all server routes
+func newRouter(conf config.ConfigData, modelCtx *modelContext) (http.Handler, error) {
+	assets := getAssets(conf.AssetDir)
+	templateFS, err := fs.Sub(assets, templateDir)
+	if err != nil {
+		return nil, err
+	}
+	staticFS, err := fs.Sub(assets, staticDir)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := newTemplateRegistry(templateFS, conf.Dev)
+	if err != nil {
+		return nil, err
+	}
+	requestHandler := newRequestHandler(modelCtx, templates)
 
-	schema, err := getSchema("./schema.graphql", modelCtx.repoConnection)
+	schema, err := getSchema(assets, modelCtx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	router := mux.NewRouter()
 
-	router.Handle("/api", &relay.Handler{Schema: schema})
+	router.Use(withMetrics, withTracing, requestHandler.Recover)
 
-	router.HandleFunc("/admin", requestHandler.AdminPage)
+	router.Handle("/api", withPosterInfo(&relay.Handler{Schema: schema}))
+	router.HandleFunc("/healthz", requestHandler.Healthz).Methods("GET")
+	router.HandleFunc("/readyz", requestHandler.Readyz).Methods("GET")
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(func(next http.Handler) http.Handler {
+		return adminAuth(conf.Admin, next)
+	}, adminSameOrigin)
+	admin.HandleFunc("", requestHandler.AdminPage)
+	admin.HandleFunc("/board", requestHandler.AdminBoardPage).Methods("GET")
+	admin.HandleFunc("/board/{board}", requestHandler.AdminThreadPage).Methods("GET")
+	admin.HandleFunc("/board/{board}/captcha", requestHandler.AdminToggleCaptcha).Methods("POST")
+	admin.HandleFunc("/thread/{id:[0-9]+}/sticky", requestHandler.AdminToggleSticky).Methods("POST")
+	admin.HandleFunc("/thread/{id:[0-9]+}/lock", requestHandler.AdminToggleLock).Methods("POST")
+	admin.HandleFunc("/log", requestHandler.AdminModLogPage).Methods("GET")
+	admin.HandleFunc("/log.jsonl", requestHandler.AdminModLogExport).Methods("GET")
+	admin.HandleFunc("/report", requestHandler.AdminReportPage).Methods("GET")
+	admin.HandleFunc("/report/{id:[0-9]+}/dismiss", requestHandler.AdminDismissReport).Methods("POST")
+	admin.HandleFunc("/report/{id:[0-9]+}/delete", requestHandler.AdminDeleteReported).Methods("POST")
+	admin.HandleFunc("/report/{id:[0-9]+}/ban", requestHandler.AdminBanReported).Methods("POST")
+	admin.HandleFunc("/filter", requestHandler.AdminFilterPage).Methods("GET")
+	admin.HandleFunc("/filter", requestHandler.AdminAddFilter).Methods("POST")
+	admin.HandleFunc("/filter/{id:[0-9]+}/delete", requestHandler.AdminDeleteFilter).Methods("POST")
+	admin.HandleFunc("/ban", requestHandler.AdminBanPage).Methods("GET")
+	admin.HandleFunc("/ban", requestHandler.AdminAddBan).Methods("POST")
+	admin.HandleFunc("/ban/{id:[0-9]+}/lift", requestHandler.AdminLiftBan).Methods("POST")
+
+	router.HandleFunc("/ban/{id:[0-9]+}/appeal", requestHandler.BanAppeal).Methods("POST")
+	router.HandleFunc("/post/{id:[0-9]+}/report", requestHandler.ReportPost).Methods("POST")
+	router.HandleFunc("/captcha/{id:[0-9a-f-]+}.png", requestHandler.CaptchaImage).Methods("GET")
 	router.HandleFunc("/{board}", requestHandler.BoardPage).Methods("GET")
 	router.HandleFunc("/{board}", requestHandler.AddThread).Methods("POST")
 	router.HandleFunc("/thread/{id:[0-9]+}", requestHandler.ThreadPage).Methods("GET")
@@ -66,16 +262,51 @@ func (s *Server) Run() {
 	router.HandleFunc("/author/{author}", requestHandler.AuthorPage)
 	router.HandleFunc("/", requestHandler.MainPage)
 
-	router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("./static"))))
+	router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.FS(staticFS))))
 	router.PathPrefix("/media/").Handler(http.StripPrefix("/media/", http.FileServer(http.Dir("./media"))))
+	router.NotFoundHandler = http.HandlerFunc(requestHandler.NotFound)
 
-	s.gracefulShutdown(func() {
-		// todo: att shutdown actions
-	})
+	return withRequestID(withClientIP(conf.HTTP.ClientIPHeader, router)), nil
+}
 
-	log.Println("starting server...")
-	err = http.ListenAndServe(":8000", router)
-	if err != nil {
-		log.Fatal(err)
+// newHTTPServer creates http server with configured timeouts and protocols
+func (s *Server) newHTTPServer(handler http.Handler) *http.Server {
+	conf := s.conf.HTTP
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if conf.HTTP2 {
+		if conf.TLSCert != "" {
+			protocols.SetHTTP2(true)
+		} else {
+			protocols.SetUnencryptedHTTP2(true)
+		}
+	}
+
+	return &http.Server{
+		Handler:           handler,
+		ReadTimeout:       conf.ReadTimeout,
+		ReadHeaderTimeout: conf.ReadHeaderTimeout,
+		WriteTimeout:      conf.WriteTimeout,
+		IdleTimeout:       conf.IdleTimeout,
+		MaxHeaderBytes:    conf.MaxHeaderBytes,
+		Protocols:         protocols,
+	}
+}
+
+// listen opens tcp listener, or unix socket listener if the address starts with "unix:".
+// Stale socket file of the previous run is removed
+func listen(address string) (net.Listener, error) {
+	socketPath, isUnix := strings.CutPrefix(address, "unix:")
+	if !isUnix {
+		return net.Listen("tcp", address)
+	}
+
+	if info, err := os.Stat(socketPath); err == nil && info.Mode()&os.ModeSocket != 0 {
+		err = os.Remove(socketPath)
+		if err != nil {
+			return nil, err
+		}
 	}
+	return net.Listen("unix", socketPath)
 }
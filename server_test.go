@@ -0,0 +1,636 @@
+package gochan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ilyakaznacheev/gochan/config"
+	"github.com/ilyakaznacheev/gochan/db"
+	"github.com/ilyakaznacheev/gochan/model"
+)
+
+const (
+	testAdminUser     = "admin"
+	testAdminPassword = "password"
+	testBannedAuthor  = "banned"
+)
+
+// testServer is a router on memory storage seeded with a board, a thread with its post,
+// a report on the post, a filter, a ban and a captcha challenge
+type testServer struct {
+	handler http.Handler
+	model   *modelContext
+
+	thread  model.ThreadKey
+	post    model.PostKey
+	report  model.ReportKey
+	filter  model.FilterKey
+	ban     model.BanKey
+	captcha model.CaptchaKey
+}
+
+// newTestServer creates test server, memory storage is used unless options change it
+func newTestServer(t *testing.T, options ...func(*config.ConfigData)) *testServer {
+	t.Helper()
+
+	conf := config.GetDefaultConfig()
+	conf.Storage = "memory"
+	conf.Admin.User = testAdminUser
+	conf.Admin.Password = testAdminPassword
+	for _, option := range options {
+		option(&conf)
+	}
+
+	repoHnd, err := model.NewRepoHandler(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage, err := db.OpenStorage(context.Background(), conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modelCtx := newModelContext(&conf, repoHnd, storage)
+	t.Cleanup(func() { modelCtx.close() })
+
+	err = migrateUp(storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler, err := newRouter(conf, modelCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{handler: handler, model: modelCtx}
+
+	ctx := context.Background()
+	mod := model.ModInfo{Moderator: "test"}
+	err = modelCtx.boardModel.CreateBoard(ctx, model.Board{Key: "b", Name: "Test board"}, mod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.thread, s.post, err = modelCtx.threadModel.CreateThreadWithOP(ctx,
+		model.Thread{Title: "Test thread", AuthorID: "op", BoardName: "b", CreationDateTime: time.Now()},
+		model.Post{Author: "op", CreationDateTime: time.Now(), Text: "Test post"},
+		nil,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.report, err = modelCtx.reportModel.PutReport(ctx, s.post, "test report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.filter, err = modelCtx.filterModel.PutFilter(ctx, model.Filter{Pattern: "spam", Action: model.FilterReject}, mod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bannedAuthor := model.AuthorKey(testBannedAuthor)
+	s.ban, err = modelCtx.banModel.PutBan(ctx, model.Ban{Author: &bannedAuthor, Reason: "test ban", CreationDateTime: time.Now()}, mod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.captcha, err = modelCtx.captchaModel.NewChallenge(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// expand replaces {thread}, {post}, {report}, {filter}, {ban} and {captcha} with seeded keys
+func (s *testServer) expand(path string) string {
+	return strings.NewReplacer(
+		"{thread}", s.thread.String(),
+		"{post}", s.post.String(),
+		"{report}", fmt.Sprint(s.report),
+		"{filter}", fmt.Sprint(s.filter),
+		"{ban}", fmt.Sprint(s.ban),
+		"{captcha}", string(s.captcha),
+	).Replace(path)
+}
+
+// newRequest creates request with url-encoded form, if it is set
+func (s *testServer) newRequest(method, path string, form url.Values) *http.Request {
+	if form == nil {
+		return httptest.NewRequest(method, s.expand(path), nil)
+	}
+	r := httptest.NewRequest(method, s.expand(path), strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+// newJSONRequest creates POST request with JSON body
+func (s *testServer) newJSONRequest(path, body string) *http.Request {
+	r := httptest.NewRequest("POST", s.expand(path), strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+// serve handles request by the router
+func (s *testServer) serve(r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestRoutes(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		form     url.Values
+		json     string // request body, sent instead of the form
+		author   string
+		admin    bool
+		status   int
+		location string
+		contains string
+		check    func(*testing.T, *testServer)
+	}{
+		{name: "home", method: "GET", path: "/", status: http.StatusOK, contains: "Test board"},
+		{name: "healthz", method: "GET", path: "/healthz", status: http.StatusOK, contains: "ok"},
+		{name: "readyz", method: "GET", path: "/readyz", status: http.StatusOK, contains: `"memory":"ok"`},
+		{name: "metrics", method: "GET", path: "/metrics", status: http.StatusOK, contains: "gochan_"},
+		{
+			name: "api", method: "POST", path: "/api",
+			json:   `{"query": "{ getBoard(id: \"b\") { id title } }"}`,
+			status: http.StatusOK, contains: `"title":"Test board"`,
+		},
+		{name: "board", method: "GET", path: "/b", status: http.StatusOK, contains: "Test thread"},
+		{name: "unknown board", method: "GET", path: "/none", status: http.StatusNotFound},
+		{
+			name: "add thread", method: "POST", path: "/b",
+			form:   url.Values{"title": {"New thread"}, "message": {"New post"}},
+			status: http.StatusFound, location: "/b",
+			check: func(t *testing.T, s *testServer) {
+				w := s.serve(s.newRequest("GET", "/b", nil))
+				if !strings.Contains(w.Body.String(), "New thread") {
+					t.Error("new thread isn't shown on the board")
+				}
+			},
+		},
+		{
+			name: "add thread banned", method: "POST", path: "/b",
+			form:   url.Values{"title": {"New thread"}, "message": {"New post"}},
+			author: testBannedAuthor,
+			status: http.StatusForbidden,
+		},
+		{
+			name: "add thread filtered", method: "POST", path: "/b",
+			form:   url.Values{"title": {"New thread"}, "message": {"spam"}},
+			status: http.StatusBadRequest,
+		},
+		{name: "thread", method: "GET", path: "/thread/{thread}", status: http.StatusOK, contains: "Test post"},
+		{name: "unknown thread", method: "GET", path: "/thread/404", status: http.StatusNotFound},
+		{
+			name: "add message", method: "POST", path: "/thread/{thread}",
+			form:   url.Values{"message": {"Reply"}},
+			status: http.StatusFound, location: "/thread/{thread}",
+			check: func(t *testing.T, s *testServer) {
+				w := s.serve(s.newRequest("GET", "/thread/{thread}", nil))
+				if !strings.Contains(w.Body.String(), "Reply") {
+					t.Error("new post isn't shown in the thread")
+				}
+			},
+		},
+		{
+			name: "add message unknown thread", method: "POST", path: "/thread/404",
+			form:   url.Values{"message": {"Reply"}},
+			status: http.StatusNotFound,
+		},
+		{
+			name: "report post", method: "POST", path: "/post/{post}/report",
+			form:   url.Values{"reason": {"offtopic"}},
+			status: http.StatusFound, location: "/thread/{thread}",
+		},
+		{
+			name: "ban appeal", method: "POST", path: "/ban/{ban}/appeal",
+			form:   url.Values{"text": {"sorry"}},
+			author: testBannedAuthor,
+			status: http.StatusOK,
+		},
+		{
+			name: "ban appeal by other poster", method: "POST", path: "/ban/{ban}/appeal",
+			form:   url.Values{"text": {"sorry"}},
+			author: "other",
+			status: http.StatusForbidden,
+		},
+		{name: "captcha image", method: "GET", path: "/captcha/{captcha}.png", status: http.StatusOK},
+		{
+			name: "unknown captcha image", method: "GET", path: "/captcha/00000000-0000-0000-0000-000000000000.png",
+			status: http.StatusNotFound,
+		},
+		{name: "author", method: "GET", path: "/author/op", status: http.StatusOK, contains: "Test post"},
+		{name: "static", method: "GET", path: "/static/template/home.html", status: http.StatusOK},
+		{name: "media", method: "GET", path: "/media/img/none.png", status: http.StatusNotFound},
+
+		{name: "admin unauthorized", method: "GET", path: "/admin", status: http.StatusUnauthorized},
+		{name: "admin", method: "GET", path: "/admin", admin: true, status: http.StatusOK},
+		{name: "admin boards", method: "GET", path: "/admin/board", admin: true, status: http.StatusOK, contains: "Test board"},
+		{name: "admin threads", method: "GET", path: "/admin/board/b", admin: true, status: http.StatusOK, contains: "Test thread"},
+		{
+			name: "admin toggle captcha", method: "POST", path: "/admin/board/b/captcha", admin: true,
+			status: http.StatusFound, location: "/admin/board",
+			check: func(t *testing.T, s *testServer) {
+				boardData, err := s.model.boardModel.GetItem(context.Background(), "b")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !boardData.Captcha {
+					t.Error("captcha isn't enabled")
+				}
+			},
+		},
+		{
+			name: "admin toggle sticky", method: "POST", path: "/admin/thread/{thread}/sticky", admin: true,
+			status: http.StatusFound, location: "/admin/board/b",
+			check: func(t *testing.T, s *testServer) {
+				threadData, err := s.model.threadModel.GetThread(context.Background(), s.thread)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !threadData.Sticky {
+					t.Error("thread isn't sticky")
+				}
+			},
+		},
+		{
+			name: "admin toggle lock", method: "POST", path: "/admin/thread/{thread}/lock", admin: true,
+			status: http.StatusFound, location: "/admin/board/b",
+			check: func(t *testing.T, s *testServer) {
+				w := s.serve(s.newRequest("POST", "/thread/{thread}", url.Values{"message": {"Reply"}}))
+				if w.Code != http.StatusForbidden {
+					t.Errorf("posting into locked thread: want status %d, got %d", http.StatusForbidden, w.Code)
+				}
+			},
+		},
+		{name: "admin log", method: "GET", path: "/admin/log", admin: true, status: http.StatusOK},
+		{name: "admin log export", method: "GET", path: "/admin/log.jsonl", admin: true, status: http.StatusOK, contains: `"board.create"`},
+		{name: "admin reports", method: "GET", path: "/admin/report", admin: true, status: http.StatusOK, contains: "test report"},
+		{
+			name: "admin dismiss report", method: "POST", path: "/admin/report/{report}/dismiss", admin: true,
+			status: http.StatusFound, location: "/admin/report",
+			check: func(t *testing.T, s *testServer) {
+				queue, err := s.model.reportModel.GetQueue(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(queue) != 0 {
+					t.Errorf("report is still open")
+				}
+			},
+		},
+		{
+			name: "admin delete reported", method: "POST", path: "/admin/report/{report}/delete", admin: true,
+			status: http.StatusFound, location: "/admin/report",
+			check: func(t *testing.T, s *testServer) {
+				_, err := s.model.postModel.GetPost(context.Background(), s.post)
+				if err == nil {
+					t.Error("reported post isn't deleted")
+				}
+				queue, err := s.model.reportModel.GetQueue(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(queue) != 0 {
+					t.Error("report of deleted post is still open")
+				}
+				actionList, err := s.model.modActionModel.GetList(context.Background(), model.ModActionFilter{Action: model.ModPostDelete, Board: "b"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(actionList) != 1 {
+					t.Error("post deletion isn't logged with its board")
+				}
+				actionList, err = s.model.modActionModel.GetList(context.Background(), model.ModActionFilter{Action: model.ModReportResolve})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(actionList) != 1 {
+					t.Error("report resolution isn't logged")
+				}
+			},
+		},
+		{
+			name: "admin ban reported", method: "POST", path: "/admin/report/{report}/ban", admin: true,
+			form:   url.Values{"reason": {"rules"}, "hours": {"1"}},
+			status: http.StatusFound, location: "/admin/report",
+			check: func(t *testing.T, s *testServer) {
+				banData, err := s.model.banModel.CheckBan(context.Background(), "", "op", "b")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if banData == nil {
+					t.Error("author of the reported post isn't banned")
+				}
+			},
+		},
+		{name: "admin filters", method: "GET", path: "/admin/filter", admin: true, status: http.StatusOK, contains: "spam"},
+		{
+			name: "admin add filter", method: "POST", path: "/admin/filter", admin: true,
+			form:   url.Values{"pattern": {"eggs"}, "action": {"reject"}},
+			status: http.StatusFound, location: "/admin/filter",
+		},
+		{
+			name: "admin add invalid filter", method: "POST", path: "/admin/filter", admin: true,
+			form:   url.Values{"pattern": {"eggs"}, "action": {"explode"}},
+			status: http.StatusBadRequest,
+		},
+		{
+			name: "admin delete filter", method: "POST", path: "/admin/filter/{filter}/delete", admin: true,
+			status: http.StatusFound, location: "/admin/filter",
+			check: func(t *testing.T, s *testServer) {
+				actionList, err := s.model.modActionModel.GetList(context.Background(), model.ModActionFilter{Action: model.ModFilterDelete})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(actionList) != 1 || !strings.Contains(actionList[0].Reason, `"spam"`) {
+					t.Error("filter deletion isn't logged with the filter")
+				}
+			},
+		},
+		{name: "admin bans", method: "GET", path: "/admin/ban", admin: true, status: http.StatusOK, contains: "test ban"},
+		{
+			name: "admin add ban", method: "POST", path: "/admin/ban", admin: true,
+			form:   url.Values{"author": {"troll"}, "reason": {"trolling"}},
+			status: http.StatusFound, location: "/admin/ban",
+		},
+		{
+			name: "admin lift ban", method: "POST", path: "/admin/ban/{ban}/lift", admin: true,
+			status: http.StatusFound, location: "/admin/ban",
+			check: func(t *testing.T, s *testServer) {
+				banData, err := s.model.banModel.CheckBan(context.Background(), "", testBannedAuthor, "b")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if banData != nil {
+					t.Error("ban isn't lifted")
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+
+			r := s.newRequest(tt.method, tt.path, tt.form)
+			if tt.json != "" {
+				r = s.newJSONRequest(tt.path, tt.json)
+			}
+			if tt.author != "" {
+				r.AddCookie(&http.Cookie{Name: "author_id", Value: tt.author})
+			}
+			if tt.admin {
+				r.SetBasicAuth(testAdminUser, testAdminPassword)
+				r.Header.Set("Origin", "http://"+r.Host)
+			}
+			w := s.serve(r)
+
+			if w.Code != tt.status {
+				t.Fatalf("want status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.location != "" && w.Header().Get("Location") != s.expand(tt.location) {
+				t.Errorf("want redirect to %s, got %q", s.expand(tt.location), w.Header().Get("Location"))
+			}
+			if !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("response doesn't contain %q: %s", tt.contains, w.Body.String())
+			}
+			if w.Header().Get("X-Request-ID") == "" {
+				t.Error("response has no request ID")
+			}
+			if tt.check != nil {
+				tt.check(t, s)
+			}
+		})
+	}
+}
+
+func TestErrorPages(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		code     string
+		contains string
+	}{
+		{name: "not found", err: model.ErrNotFound, status: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "banned", err: model.ErrBanned, status: http.StatusForbidden, code: "BANNED"},
+		{name: "invalid input", err: model.ErrValidation, status: http.StatusBadRequest, code: "BAD_INPUT"},
+		{
+			name: "rate limited", err: fmt.Errorf("posting: %w", model.ErrRateLimited),
+			status: http.StatusTooManyRequests, code: "RATE_LIMITED", contains: "posting too fast",
+		},
+		{
+			name: "internal", err: errors.New("sql: connection refused"),
+			status: http.StatusInternalServerError, code: "INTERNAL",
+		},
+	}
+
+	s := newTestServer(t)
+	templateFS, err := fs.Sub(getAssets(""), templateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, err := newTemplateRegistry(templateFS, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rh := &ChanRequestHandler{s.model, templates}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rh.renderError(w, httptest.NewRequest("GET", "/", nil), tt.err)
+
+			if w.Code != tt.status {
+				t.Errorf("want status %d, got %d", tt.status, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("page doesn't contain %q: %s", tt.contains, w.Body.String())
+			}
+			if strings.Contains(w.Body.String(), "connection refused") {
+				t.Error("internal error details are shown")
+			}
+			if code := errorCode(tt.err); code != tt.code {
+				t.Errorf("want GraphQL error code %s, got %s", tt.code, code)
+			}
+		})
+	}
+}
+
+func TestAdminSameOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		status  int
+	}{
+		{name: "page without origin", method: "GET", status: http.StatusOK},
+		{name: "same origin", method: "POST", headers: map[string]string{"Origin": "http://example.com"}, status: http.StatusFound},
+		{name: "same referer", method: "POST", headers: map[string]string{"Referer": "http://example.com/admin/ban"}, status: http.StatusFound},
+		{name: "without origin", method: "POST", status: http.StatusForbidden},
+		{name: "other origin", method: "POST", headers: map[string]string{"Origin": "http://evil.example"}, status: http.StatusForbidden},
+		{name: "other referer", method: "POST", headers: map[string]string{"Referer": "http://evil.example/admin/ban"}, status: http.StatusForbidden},
+		{name: "null origin", method: "POST", headers: map[string]string{"Origin": "null"}, status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+
+			r := s.newRequest(tt.method, "/admin/ban", url.Values{"author": {"troll"}, "reason": {"trolling"}})
+			r.SetBasicAuth(testAdminUser, testAdminPassword)
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+			w := s.serve(r)
+
+			if w.Code != tt.status {
+				t.Errorf("want status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		remoteAddr string
+		values     map[string][]string
+		want       string
+	}{
+		{name: "peer", remoteAddr: "192.0.2.1:1234", want: "192.0.2.1"},
+		{name: "unix peer", remoteAddr: "@", want: ""},
+		{name: "empty peer", remoteAddr: "", want: ""},
+		{
+			name: "untrusted header", remoteAddr: "192.0.2.1:1234",
+			values: map[string][]string{"X-Real-Ip": {"203.0.113.7"}},
+			want:   "192.0.2.1",
+		},
+		{
+			name: "real ip", header: "X-Real-IP", remoteAddr: "@",
+			values: map[string][]string{"X-Real-Ip": {"203.0.113.7"}},
+			want:   "203.0.113.7",
+		},
+		{
+			name: "forwarded for", header: "X-Forwarded-For", remoteAddr: "@",
+			values: map[string][]string{"X-Forwarded-For": {"198.51.100.1, 203.0.113.7"}},
+			want:   "203.0.113.7",
+		},
+		{
+			name: "forwarded for several headers", header: "X-Forwarded-For", remoteAddr: "@",
+			values: map[string][]string{"X-Forwarded-For": {"198.51.100.1", "203.0.113.7"}},
+			want:   "203.0.113.7",
+		},
+		{
+			name: "invalid header", header: "X-Real-IP", remoteAddr: "192.0.2.1:1234",
+			values: map[string][]string{"X-Real-Ip": {"unknown"}},
+			want:   "192.0.2.1",
+		},
+		{name: "missing header", header: "X-Real-IP", remoteAddr: "@", want: ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for key, values := range tt.values {
+				r.Header[key] = values
+			}
+
+			var got string
+			withClientIP(tt.header, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = getClientIP(r)
+			})).ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("want client IP %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestUnixSocket posts over unix socket listener, ban query gets no peer IP there
+func TestUnixSocket(t *testing.T) {
+	s := newTestServer(t, func(conf *config.ConfigData) {
+		conf.Storage = "sqlite"
+		conf.Database.Path = ":memory:"
+		conf.HTTP.ClientIPHeader = "X-Real-IP"
+	})
+	bannedIP := "203.0.113.7"
+	_, err := s.model.banModel.PutBan(context.Background(), model.Ban{IP: &bannedIP, Reason: "ip ban", CreationDateTime: time.Now()}, model.ModInfo{Moderator: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "gochan.sock")
+	listener, err := listen("unix:" + socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := &http.Server{Handler: s.handler}
+	go httpServer.Serve(listener)
+	t.Cleanup(func() { httpServer.Close() })
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+			},
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		clientIP string
+		status   int
+	}{
+		{name: "thread without proxy header", path: "/b", status: http.StatusFound},
+		{name: "thread", path: "/b", clientIP: "198.51.100.1", status: http.StatusFound},
+		{name: "thread from banned IP", path: "/b", clientIP: bannedIP, status: http.StatusForbidden},
+		{name: "reply without proxy header", path: "/thread/{thread}", status: http.StatusFound},
+		{name: "reply from banned IP", path: "/thread/{thread}", clientIP: bannedIP, status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"title": {"New thread"}, "message": {"New post"}}
+			r, err := http.NewRequest("POST", "http://gochan"+s.expand(tt.path), strings.NewReader(form.Encode()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.clientIP != "" {
+				r.Header.Set("X-Real-IP", tt.clientIP)
+			}
+
+			resp, err := client.Do(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("want status %d, got %d", tt.status, resp.StatusCode)
+			}
+		})
+	}
+}
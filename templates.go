@@ -0,0 +1,178 @@
+package gochan
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+)
+
+const (
+	// templateLayoutDir contains base page layouts
+	templateLayoutDir = "layout"
+	// templatePartialDir contains blocks shared between pages
+	templatePartialDir = "partial"
+	// templateBase is a name of the root layout template every page executes
+	templateBase = "base"
+	// templateContent is a name of the block every page has to define
+	templateContent = "content"
+)
+
+// templateFuncs are helper functions available in all templates
+var templateFuncs = template.FuncMap{
+	"mediaURL": func(filePath string) string {
+		return "/" + filePath
+	},
+	"captchaURL": func(captchaID string) string {
+		return "/captcha/" + captchaID + ".png"
+	},
+}
+
+// templateRegistry keeps page templates parsed together with shared layouts and partials.
+// In development mode templates are re-parsed when any file in the directory changes
+type templateRegistry struct {
+	fsys fs.FS
+	dev  bool
+
+	mu      sync.RWMutex
+	pages   map[string]*template.Template
+	modTime time.Time
+}
+
+// newTemplateRegistry parses all page templates of the file system
+func newTemplateRegistry(fsys fs.FS, dev bool) (*templateRegistry, error) {
+	t := &templateRegistry{
+		fsys: fsys,
+		dev:  dev,
+	}
+	modTime, err := t.lastModified()
+	if err != nil {
+		return nil, err
+	}
+	err = t.parse(modTime)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parse builds every top-level page on top of layouts and partials
+func (t *templateRegistry) parse(modTime time.Time) error {
+	base := template.New(templateBase).Funcs(templateFuncs)
+	for _, dir := range []string{templateLayoutDir, templatePartialDir} {
+		fileList, err := fs.Glob(t.fsys, path.Join(dir, "*.html"))
+		if err != nil {
+			return err
+		}
+		if len(fileList) == 0 {
+			continue
+		}
+		base, err = base.ParseFS(t.fsys, fileList...)
+		if err != nil {
+			return err
+		}
+	}
+
+	pageList, err := fs.Glob(t.fsys, "*.html")
+	if err != nil {
+		return err
+	}
+
+	pages := make(map[string]*template.Template, len(pageList))
+	for _, pageName := range pageList {
+		page, err := base.Clone()
+		if err != nil {
+			return err
+		}
+		page, err = page.ParseFS(t.fsys, pageName)
+		if err != nil {
+			return err
+		}
+		if page.Lookup(templateContent) == nil {
+			return fmt.Errorf("template %s: %s block is not defined", pageName, templateContent)
+		}
+		pages[pageName] = page
+	}
+
+	t.mu.Lock()
+	t.pages = pages
+	t.modTime = modTime
+	t.mu.Unlock()
+	return nil
+}
+
+// lastModified returns the latest modification time of template files
+func (t *templateRegistry) lastModified() (time.Time, error) {
+	var modTime time.Time
+	err := fs.WalkDir(t.fsys, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+		return nil
+	})
+	return modTime, err
+}
+
+// lookup returns parsed page, re-parsing templates first if they changed in development mode
+func (t *templateRegistry) lookup(name string) (*template.Template, error) {
+	if t.dev {
+		modTime, err := t.lastModified()
+		if err != nil {
+			return nil, err
+		}
+		t.mu.RLock()
+		changed := modTime.After(t.modTime)
+		t.mu.RUnlock()
+		if changed {
+			slog.Info("templates changed, reloading")
+			err = t.parse(modTime)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	t.mu.RLock()
+	page, ok := t.pages[name]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("template %s: %w", name, fs.ErrNotExist)
+	}
+	return page, nil
+}
+
+// render executes the page with given status.
+// The page is rendered into buffer first, so a broken template doesn't send half of the page
+func (t *templateRegistry) render(w http.ResponseWriter, status int, name string, data interface{}) error {
+	page, err := t.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = page.ExecuteTemplate(&buf, templateBase, data)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, err = buf.WriteTo(w)
+	if err != nil {
+		// the status is already sent, so the client just gets a cut page
+		slog.Warn("can't write page", "template", name, "err", err)
+	}
+	return nil
+}
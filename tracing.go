@@ -0,0 +1,106 @@
+package gochan
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/graph-gophers/graphql-go/introspection"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ilyakaznacheev/gochan/tracing"
+)
+
+// withTracing starts span of the matched route, continuing trace of incoming traceparent header
+func withTracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		attrs := []attribute.KeyValue{
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", route),
+		}
+		if boardName, ok := mux.Vars(r)["board"]; ok {
+			attrs = append(attrs, tracing.BoardKey.String(boardName))
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attrs...),
+		)
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// gqlArgAttrs maps resolver arguments to span attributes.
+// Only keys are recorded, post text and author IDs must not get into traces
+var gqlArgAttrs = map[string]attribute.Key{
+	"getBoard.id":        tracing.BoardKey,
+	"getThread.id":       tracing.ThreadKey,
+	"getPost.id":         tracing.PostKey,
+	"getCaptcha.boardID": tracing.BoardKey,
+	"addPost.threadID":   tracing.ThreadKey,
+	"addThread.boardID":  tracing.BoardKey,
+	"reportPost.postID":  tracing.PostKey,
+}
+
+// gqlTracer traces GraphQL queries and resolvers of non-trivial fields
+type gqlTracer struct{}
+
+func (gqlTracer) TraceQuery(ctx context.Context, queryString string, operationName string, variables map[string]interface{}, varTypes map[string]*introspection.Type) (context.Context, func([]*gqlerrors.QueryError)) {
+	ctx, span := tracing.Start(ctx, "graphql", attribute.String("graphql.operation.name", operationName))
+	return ctx, func(errs []*gqlerrors.QueryError) {
+		if len(errs) > 0 {
+			span.SetStatus(codes.Error, errs[0].Error())
+		}
+		span.End()
+	}
+}
+
+func (gqlTracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, args map[string]interface{}) (context.Context, func(*gqlerrors.QueryError)) {
+	if trivial {
+		return ctx, func(*gqlerrors.QueryError) {}
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("graphql.type", typeName),
+		attribute.String("graphql.field", fieldName),
+	}
+	for name, value := range args {
+		if key, ok := gqlArgAttrs[fieldName+"."+name]; ok {
+			attrs = append(attrs, key.String(fmt.Sprint(value)))
+		}
+	}
+
+	ctx, span := tracing.Start(ctx, "graphql "+typeName+"."+fieldName, attrs...)
+	return ctx, func(err *gqlerrors.QueryError) {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+func (gqlTracer) TraceValidation(ctx context.Context) func([]*gqlerrors.QueryError) {
+	_, span := tracing.Start(ctx, "graphql validation")
+	return func(errs []*gqlerrors.QueryError) {
+		if len(errs) > 0 {
+			span.SetStatus(codes.Error, errs[0].Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,82 @@
+// Package tracing configures OpenTelemetry tracing and provides span helpers
+// shared by HTTP handlers, models and DACs
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ilyakaznacheev/gochan/config"
+)
+
+// instrumentationName is a name of the tracer of gochan spans
+const instrumentationName = "github.com/ilyakaznacheev/gochan"
+
+// span attributes
+const (
+	BoardKey       = attribute.Key("gochan.board")
+	ThreadKey      = attribute.Key("gochan.thread")
+	PostKey        = attribute.Key("gochan.post")
+	CacheEntityKey = attribute.Key("gochan.cache.entity")
+	CacheHitKey    = attribute.Key("gochan.cache.hit")
+)
+
+// Tracer returns gochan tracer of the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts internal span, it is a child of the context span
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Fail records error in the span, nil error is ignored
+func Fail(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// NewProvider creates tracer provider exporting sampled spans in batches.
+// Any exporter fits, e.g. tracetest.InMemoryExporter in tests
+func NewProvider(exporter sdktrace.SpanExporter, conf config.ConfigTracing) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", conf.ServiceName),
+		)),
+	)
+}
+
+// Setup sets global tracer provider exporting spans to OTLP/HTTP collector.
+// W3C trace context is propagated in any case, so gochan doesn't break traces of a proxy.
+// Returned function flushes pending spans, it has to be called on exit
+func Setup(ctx context.Context, conf config.ConfigTracing) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	if conf.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(conf.Endpoint))
+	if err != nil {
+		return nil, err
+	}
+	provider := NewProvider(exporter, conf)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}